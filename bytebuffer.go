@@ -0,0 +1,176 @@
+package cyclicbuffer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ByteBuffer is a thread safe cyclic buffer of bytes
+// I use this buffer for a fixed size RAM log of raw text
+// When the buffer is full Write() overwrites the oldest bytes
+type ByteBuffer struct {
+	data []byte
+	// start is the position of the oldest byte
+	start  int
+	length int
+	mutex  *sync.RWMutex
+}
+
+// NewByteBuffer creates a buffer
+func NewByteBuffer(size int) *ByteBuffer {
+	return &ByteBuffer{
+		mutex: &sync.RWMutex{},
+		data:  make([]byte, size),
+	}
+}
+
+// Write implements io.Writer
+// If len(p) exceeds the capacity only the trailing bytes remain
+// Write always succeeds
+func (bb *ByteBuffer) Write(p []byte) (int, error) {
+	bb.mutex.Lock()
+	defer bb.mutex.Unlock()
+	bb.write(p)
+	return len(p), nil
+}
+
+// write does the actual work, the caller holds the mutex
+func (bb *ByteBuffer) write(p []byte) {
+	size := len(bb.data)
+	if len(p) >= size {
+		copy(bb.data, p[len(p)-size:])
+		bb.start = 0
+		bb.length = size
+		return
+	}
+	index := (bb.start + bb.length) % size
+	n := copy(bb.data[index:], p)
+	copy(bb.data, p[n:])
+	bb.length += len(p)
+	if bb.length > size {
+		// The oldest bytes are overwritten
+		bb.start = (bb.start + bb.length - size) % size
+		bb.length = size
+	}
+}
+
+// Read implements io.Reader
+// Read removes the oldest bytes from the buffer and copies them to p
+// Returns io.EOF if the buffer is empty. Write and Read make a pipe
+// which drops the oldest bytes if the reader is slow
+func (bb *ByteBuffer) Read(p []byte) (int, error) {
+	bb.mutex.Lock()
+	defer bb.mutex.Unlock()
+	if bb.length == 0 {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := len(p)
+	if n > bb.length {
+		n = bb.length
+	}
+	copied := copy(p[:n], bb.data[bb.start:])
+	copy(p[copied:n], bb.data)
+	bb.start = (bb.start + n) % len(bb.data)
+	bb.length -= n
+	return n, nil
+}
+
+// Bytes returns a copy of the stored bytes, oldest first
+func (bb *ByteBuffer) Bytes() []byte {
+	bb.mutex.RLock()
+	defer bb.mutex.RUnlock()
+	return bb.copyAt(0, bb.length)
+}
+
+// copyAt returns a copy of n bytes starting at the offset from the
+// oldest byte. The caller holds the mutex and checks the range
+func (bb *ByteBuffer) copyAt(offset, n int) []byte {
+	res := make([]byte, n)
+	if n == 0 {
+		return res
+	}
+	copied := copy(res, bb.data[(bb.start+offset)%len(bb.data):])
+	copy(res[copied:], bb.data)
+	return res
+}
+
+// recordHeader is the size of the length of a record, see WriteRecord()
+const recordHeader = 4
+
+// WriteRecord adds a record to the buffer. A record is the length of
+// the payload, 4 bytes big endian, and the payload. If there is no
+// room WriteRecord removes the oldest records, the buffer never keeps
+// a part of a record. Returns an error if the record exceeds Cap()
+// Do not mix WriteRecord() with Write() and Read() in one buffer
+func (bb *ByteBuffer) WriteRecord(p []byte) error {
+	bb.mutex.Lock()
+	defer bb.mutex.Unlock()
+	size := recordHeader + len(p)
+	if size > len(bb.data) {
+		return fmt.Errorf("%w: record of %d bytes exceeds the capacity %d", ErrInvalidSize, size, len(bb.data))
+	}
+	for bb.length+size > len(bb.data) {
+		n, ok := bb.recordAt(0)
+		if !ok {
+			// This is not a record, I drop the garbage
+			n = bb.length
+		}
+		bb.start = (bb.start + n) % len(bb.data)
+		bb.length -= n
+	}
+	var header [recordHeader]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(p)))
+	bb.write(header[:])
+	bb.write(p)
+	return nil
+}
+
+// ReadRecords returns copies of the records, oldest first
+// ReadRecords does not remove the records, see WriteRecord()
+// The bytes after the last complete record are skipped
+func (bb *ByteBuffer) ReadRecords() [][]byte {
+	bb.mutex.RLock()
+	defer bb.mutex.RUnlock()
+	res := [][]byte{}
+	for offset := 0; ; {
+		n, ok := bb.recordAt(offset)
+		if !ok {
+			return res
+		}
+		res = append(res, bb.copyAt(offset+recordHeader, n-recordHeader))
+		offset += n
+	}
+}
+
+// recordAt returns the size of the record, including the header, at
+// the offset from the oldest byte. Returns false if there is no
+// complete record. The caller holds the mutex
+func (bb *ByteBuffer) recordAt(offset int) (int, bool) {
+	if offset+recordHeader > bb.length {
+		return 0, false
+	}
+	n := recordHeader + int(binary.BigEndian.Uint32(bb.copyAt(offset, recordHeader)))
+	if n < recordHeader || offset+n > bb.length {
+		return 0, false
+	}
+	return n, true
+}
+
+// Len returns the number of bytes stored in the buffer
+func (bb *ByteBuffer) Len() int {
+	bb.mutex.RLock()
+	defer bb.mutex.RUnlock()
+	return bb.length
+}
+
+// Cap returns the capacity of the buffer
+func (bb *ByteBuffer) Cap() int {
+	bb.mutex.RLock()
+	defer bb.mutex.RUnlock()
+	return len(bb.data)
+}
@@ -0,0 +1,108 @@
+package cyclicbuffer
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestByteBuffer(t *testing.T) {
+	bb := NewByteBuffer(5)
+	bb.Write([]byte("ab"))
+	if string(bb.Bytes()) != "ab" {
+		t.Fatal()
+	}
+	bb.Write([]byte("cde"))
+	if string(bb.Bytes()) != "abcde" || bb.Len() != 5 {
+		t.Fatal()
+	}
+	bb.Write([]byte("fg"))
+	if string(bb.Bytes()) != "cdefg" {
+		t.Fatal(string(bb.Bytes()))
+	}
+	bb.Write([]byte("0123456789"))
+	if string(bb.Bytes()) != "56789" {
+		t.Fatal()
+	}
+	// compare to a naive model
+	ref := ""
+	b2 := NewByteBuffer(7)
+	for i := 0; i < 50; i++ {
+		s := fmt.Sprint(i * i)
+		ref += s
+		if len(ref) > 7 {
+			ref = ref[len(ref)-7:]
+		}
+		b2.Write([]byte(s))
+		if string(b2.Bytes()) != ref {
+			t.Fatal(i)
+		}
+	}
+	NewByteBuffer(0).Write([]byte("x"))
+}
+
+func TestByteBufferRead(t *testing.T) {
+	bb := NewByteBuffer(5)
+	p := make([]byte, 3)
+	if n, err := bb.Read(p); n != 0 || err != io.EOF {
+		t.Fatal(n, err)
+	}
+	bb.Write([]byte("abcd"))
+	if n, err := bb.Read(p); n != 3 || err != nil || string(p) != "abc" {
+		t.Fatal(n, err)
+	}
+	bb.Write([]byte("efgh"))
+	if bb.Len() != 5 {
+		t.Fatal(bb.Len())
+	}
+	out, err := io.ReadAll(bb)
+	if err != nil || string(out) != "defgh" {
+		t.Fatal(string(out), err)
+	}
+	bb.Write([]byte("1234567"))
+	out, _ = io.ReadAll(bb)
+	if string(out) != "34567" || bb.Len() != 0 {
+		t.Fatal(string(out))
+	}
+	if n, err := NewByteBuffer(0).Read(p); n != 0 || err != io.EOF {
+		t.Fatal(n, err)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	bb := NewByteBuffer(20)
+	for _, r := range []string{"aaaa", "bb", "cccccc", "", "dd"} {
+		if err := bb.WriteRecord([]byte(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// cccccc (10), "" (4), dd (6) fit 20 bytes
+	recs := bb.ReadRecords()
+	if len(recs) != 3 || string(recs[0]) != "cccccc" || len(recs[1]) != 0 || string(recs[2]) != "dd" {
+		t.Fatal(recs)
+	}
+	if bb.WriteRecord(make([]byte, 17)) == nil {
+		t.Fatal()
+	}
+	if err := bb.WriteRecord([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	recs = bb.ReadRecords()
+	if len(recs) != 1 || string(recs[0]) != "0123456789abcdef" {
+		t.Fatal(recs)
+	}
+	for i := 0; i < 50; i++ {
+		bb.WriteRecord([]byte(fmt.Sprint(i)))
+	}
+	recs = bb.ReadRecords()
+	if len(recs) != 3 || string(recs[2]) != "49" || string(recs[0]) != "47" {
+		t.Fatal(recs)
+	}
+	bb.Write([]byte{0, 0})
+	if recs = bb.ReadRecords(); len(recs) != 3 {
+		t.Fatal(recs)
+	}
+	if len(NewByteBuffer(0).ReadRecords()) != 0 {
+		t.Fatal()
+	}
+}
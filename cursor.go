@@ -0,0 +1,49 @@
+package cyclicbuffer
+
+// Cursor supports bidirectional navigation over a snapshot of the buffer
+// I use the cursor in a log viewer. Append does not affect an existing
+// cursor
+type Cursor struct {
+	data []interface{}
+	// index is the logical position of the current item, -1 before
+	// the first call to Next()
+	index int
+}
+
+// NewCursor returns a cursor positioned before the oldest item
+func (cb *CyclicBuffer) NewCursor() *Cursor {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return &Cursor{data: cb.get(), index: -1}
+}
+
+// Next moves the cursor to the next newer item and returns the item
+// Returns false if the current item is the newest one
+func (c *Cursor) Next() (interface{}, bool) {
+	if c.index+1 >= len(c.data) {
+		return nil, false
+	}
+	c.index++
+	return c.data[c.index], true
+}
+
+// Prev moves the cursor to the next older item and returns the item
+// Returns false if the current item is the oldest one
+func (c *Cursor) Prev() (interface{}, bool) {
+	if c.index <= 0 {
+		return nil, false
+	}
+	c.index--
+	return c.data[c.index], true
+}
+
+// Index returns the logical position of the current item, where 0 is
+// the oldest item. Returns -1 before the first call to Next()
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Len returns the number of items in the snapshot
+func (c *Cursor) Len() int {
+	return len(c.data)
+}
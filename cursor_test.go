@@ -0,0 +1,33 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	c := cb.NewCursor()
+	cb.Append(9)
+	if _, ok := c.Prev(); ok || c.Index() != -1 {
+		t.Fatal()
+	}
+	for i := 2; i < 5; i++ {
+		if v, ok := c.Next(); !ok || v != i {
+			t.Fatal(v)
+		}
+	}
+	if _, ok := c.Next(); ok || c.Index() != 2 {
+		t.Fatal()
+	}
+	for i := 3; i >= 2; i-- {
+		if v, ok := c.Prev(); !ok || v != i || c.Index() != i-2 {
+			t.Fatal(v)
+		}
+	}
+	if _, ok := c.Prev(); ok {
+		t.Fatal()
+	}
+}
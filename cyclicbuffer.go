@@ -1,7 +1,10 @@
 package cyclicbuffer
 
 import (
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // CyclicBuffer is a thread safe cyclic buffer.
@@ -12,7 +15,129 @@ type CyclicBuffer struct {
 	full  bool
 	size  int
 	index int
-	mutex *sync.Mutex
+	// start is the position of the oldest item, see Pop()
+	start int
+	// mutex is a *sync.RWMutex unless the caller supplies the lock,
+	// see NewWithMutex()
+	mutex rwLocker
+	// notEmpty is signaled by Append, see PopWait()
+	notEmpty *sync.Cond
+	// notFull is signaled by Pop, see AppendWait()
+	notFull *sync.Cond
+	// seqs keeps the sequence number of the item in every slot
+	// seq is the sequence number of the newest item, see nextSeq()
+	seqs []uint64
+	seq  uint64
+	// globalSeq is true if the buffer takes the sequence numbers from
+	// lastSeq, see SetGlobalSequence()
+	globalSeq bool
+	// version counts the modifications of the items, see GetIfChanged()
+	version uint64
+
+	evictionHandler func(evicted interface{})
+	// overflow gets the overwritten items, see SetOverflow()
+	overflow *CyclicBuffer
+	// spilled are the overwritten items for the overflow buffer,
+	// see unlock()
+	spilled []interface{}
+	// evictedBatch collects the items overwritten by AppendAll,
+	// see SetBatchEvictionHandler()
+	batchEvictionHandler func(evicted []interface{})
+	evictedBatch         []interface{}
+
+	// statistics, see Stats()
+	totalAppended uint64
+	totalEvicted  uint64
+	wraps         uint64
+
+	// dedup compares consecutive items, see SetDedup()
+	dedup func(a, b interface{}) bool
+	// globalDedup compares the new item with all items, see SetGlobalDedup()
+	globalDedup func(a, b interface{}) bool
+	repeats     uint64
+
+	observer func(event string, len, cap int)
+
+	// uniqueKey returns the key of an item, see SetUniqueKey()
+	uniqueKey func(interface{}) string
+
+	// onWatermark is called when Len() reaches watermark, see SetWatermark()
+	watermark   int
+	onWatermark func(snapshot []interface{})
+
+	binaryCodec ElemCodec
+
+	// policy tells what Append does when the buffer is full, see Policy
+	policy Policy
+
+	// middlewares process the items before Append, see AddAppendMiddleware()
+	middlewares []func(interface{}) (interface{}, bool)
+
+	// subscribers get the appended items, see Subscribe()
+	subscribers []chan interface{}
+
+	// keyIndex maps the key of an item to the slot, see SetKeyFunc()
+	keyFunc  func(interface{}) string
+	keyIndex map[string]keySlot
+
+	// rollingSum is the sum of extract(item), see SetRollingSum()
+	extract    func(interface{}) float64
+	rollingSum float64
+
+	// sizeOf limits the total size of the items, see NewSizeBounded()
+	sizeOf    func(interface{}) int
+	byteLimit int
+	byteLen   int
+
+	// panicHandler recovers the panics of the handlers, see SetPanicHandler()
+	panicHandler func(recovered interface{})
+
+	// detached is true if the derived state does not include the item
+	// in detachedSlot, see AppendReuse()
+	detached     bool
+	detachedSlot int
+
+	// prefill is the value of the free slots, see Prefill()
+	prefill interface{}
+
+	// scratch is the slice returned by GetReuse()
+	scratch []interface{}
+
+	// ops is the log of the operations, see RecordOps()
+	recording bool
+	ops       []Op
+}
+
+// lastSeq is the sequence number of the newest item in the buffers
+// which share the sequence numbers, see SetGlobalSequence()
+var lastSeq uint64
+
+// nextSeq returns a new sequence number, the caller holds the mutex
+// Every buffer counts the sequence numbers, I avoid the contention on
+// a shared counter unless the buffer uses SetGlobalSequence()
+func (cb *CyclicBuffer) nextSeq() uint64 {
+	if cb.globalSeq {
+		return atomic.AddUint64(&lastSeq, 1)
+	}
+	return cb.seq + 1
+}
+
+// SetGlobalSequence enables the sequence numbers shared by all buffers
+// which enable them. The shared numbers order the items of different
+// buffers by the time of Append, see MergeIterators(). Every Append
+// updates a shared counter, the buffers appending in parallel contend
+// for the counter. The sequence numbers of the buffer keep growing
+// after the call, see AppendSince()
+func (cb *CyclicBuffer) SetGlobalSequence(enable bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.globalSeq = enable
+	for enable {
+		last := atomic.LoadUint64(&lastSeq)
+		if last >= cb.seq || atomic.CompareAndSwapUint64(&lastSeq, last, cb.seq) {
+			break
+		}
+	}
 }
 
 // Empty returns true is the buffer is empty
@@ -22,112 +147,1306 @@ func (cb *CyclicBuffer) Empty() bool {
 
 // NotEmpty returns true is the buffer is not empty
 func (cb *CyclicBuffer) NotEmpty() bool {
-	return (cb.full || (cb.index > 0))
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return (cb.len() > 0)
+}
+
+// Full returns true if the buffer is full and the next Append
+// overwrites the oldest item
+func (cb *CyclicBuffer) Full() bool {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.full
+}
+
+// Len returns the number of items stored in the buffer
+func (cb *CyclicBuffer) Len() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.len()
+}
+
+// len is Len() for callers holding the mutex
+func (cb *CyclicBuffer) len() int {
+	if cb.full {
+		return cb.size
+	}
+	if cb.index >= cb.start {
+		return cb.index - cb.start
+	}
+	return cb.index + cb.size - cb.start
+}
+
+// Cap returns the capacity of the buffer
+func (cb *CyclicBuffer) Cap() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.size
+}
+
+// FillRatio returns Len()/Cap(), a value in the range [0, 1]
+// FillRatio of a buffer of size 0 is 0
+func (cb *CyclicBuffer) FillRatio() float64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.size == 0 {
+		return 0
+	}
+	return float64(cb.len()) / float64(cb.size)
+}
+
+// FreeSlots returns the number of Append calls which do not overwrite
+// the oldest items, Cap() - Len()
+func (cb *CyclicBuffer) FreeSlots() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.size - cb.len()
 }
 
 // New creates a buffer
+// New panics if the size is negative, see NewChecked()
+// A buffer of size 0 is valid and always empty, Append() drops the items
 func New(size int) *CyclicBuffer {
-	return &CyclicBuffer{
-		mutex: &sync.Mutex{},
+	cb, err := NewChecked(size)
+	if err != nil {
+		panic(err)
+	}
+	return cb
+}
+
+// NewChecked creates a buffer
+// Returns an error if the size is negative
+func NewChecked(size int) (*CyclicBuffer, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("%w: negative size %d", ErrInvalidSize, size)
+	}
+	cb := &CyclicBuffer{
 		data:  make([]interface{}, size),
+		seqs:  make([]uint64, size),
 		index: 0,
+		start: 0,
 		full:  false,
 		size:  size,
 	}
+	cb.initLocks()
+	return cb, nil
+}
+
+// initLocks allocates the mutex and the condition variables
+func (cb *CyclicBuffer) initLocks() {
+	cb.mutex = &sync.RWMutex{}
+	cb.notEmpty = sync.NewCond(cb.mutex)
+	cb.notFull = sync.NewCond(cb.mutex)
+}
+
+// NewFromSlice creates a full buffer containing a copy of the items
+// The capacity of the buffer is len(items)
+func NewFromSlice(items []interface{}) *CyclicBuffer {
+	cb := New(len(items))
+	cb.load(len(items), items)
+	return cb
 }
 
 // Append adds an item to the cyclic buffer
 // Returns position of the next entry
 func (cb *CyclicBuffer) Append(d interface{}) int {
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	defer cb.unlock()
+	return cb.appendLocked(d)
+}
+
+// AppendSafe is a thread safe API
+func (cb *CyclicBuffer) AppendSafe(d interface{}) int {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	return cb.appendLocked(d)
+}
+
+// Clone returns an independent copy of the buffer
+// The stored items are not deep copied. The handlers and the options
+// set by the Set...() methods are not copied. The policy and the size
+// limit, see NewSizeBounded(), are copied
+func (cb *CyclicBuffer) Clone() *CyclicBuffer {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	c := New(cb.size)
+	copy(c.data, cb.data)
+	copy(c.seqs, cb.seqs)
+	c.seq = cb.seq
+	c.version = cb.version
+	c.index = cb.index
+	c.start = cb.start
+	c.full = cb.full
+	c.totalAppended = cb.totalAppended
+	c.totalEvicted = cb.totalEvicted
+	c.wraps = cb.wraps
+	c.policy = cb.policy
+	c.sizeOf, c.byteLimit, c.byteLen = cb.sizeOf, cb.byteLimit, cb.byteLen
+	return c
+}
+
+// AppendIfNotFull adds an item to the buffer if the buffer is not full
+// Returns false if the buffer is full, the buffer is not modified
+func (cb *CyclicBuffer) AppendIfNotFull(d interface{}) bool {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	if cb.full {
+		return false
+	}
+	cb.appendLocked(d)
+	return true
+}
+
+// AppendReturningEvicted adds an item to the buffer
+// If the buffer is full returns the overwritten oldest item and true
+func (cb *CyclicBuffer) AppendReturningEvicted(d interface{}) (evicted interface{}, didEvict bool) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	res := cb.push(d)
+	return res.old, res.evicted
+}
+
+// AppendChecked adds an item to the cyclic buffer
+// Returns position of the next entry and true if the item
+// overwrote the oldest item. Only the Overwrite policy overwrites:
+// if the Reject policy drops the item AppendChecked returns -1 and
+// false, the Block policy waits for a free slot and the Grow policy
+// grows the buffer, both return false. See Policy
+func (cb *CyclicBuffer) AppendChecked(d interface{}) (nextIndex int, overwrote bool) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	res := cb.push(d)
+	if res.rejected {
+		return -1, false
+	}
+	return cb.index, res.evicted
+}
+
+// AppendDetectFull adds an item to the cyclic buffer
+// Returns position of the next entry and true if the item filled the
+// last free slot. If the buffer is already full the item overwrites the
+// oldest item and AppendDetectFull returns false
+// If the Reject policy drops the item AppendDetectFull returns -1 and
+// false. The Block policy waits for a free slot, the item which takes
+// the last free slot returns true. The Grow policy grows a full buffer,
+// the item which takes the last slot of the grown buffer returns true
+func (cb *CyclicBuffer) AppendDetectFull(d interface{}) (nextIndex int, justFilled bool) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	res := cb.push(d)
+	if res.rejected {
+		return -1, false
+	}
+	return cb.index, res.filled
+}
+
+// AppendAt adds an item to the cyclic buffer and returns the logical
+// position of the item, where 0 is the oldest item, for At() and Set()
+// The item is the newest, the position is Len()-1 after the Append.
+// If the Append overwrote the oldest item the position does not change
+// Returns -1 if the item was dropped, for example, by SetDedup()
+// The position is valid until the next call which modifies the buffer
+func (cb *CyclicBuffer) AppendAt(d interface{}) (logicalIndex int) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	if res := cb.push(d); !res.stored {
+		return -1
+	}
+	return cb.len() - 1
+}
+
+// CompareAndAppend adds an item to the buffer if the newest item is
+// equal to expectedNewest. An empty buffer matches nil
+// If eq is nil the items are compared with ==
+// Returns true if the item was appended
+func (cb *CyclicBuffer) CompareAndAppend(expectedNewest, d interface{}, eq func(a, b interface{}) bool) bool {
+	if eq == nil {
+		eq = equal
+	}
+	cb.mutex.Lock()
+	defer cb.unlock()
+	newest, ok := cb.newest()
+	if ok && !eq(newest, expectedNewest) {
+		return false
+	}
+	if !ok && expectedNewest != nil {
+		return false
+	}
+	cb.appendLocked(d)
+	return true
+}
+
+// AppendAll adds the items to the cyclic buffer in one lock
+// If there are more items than the buffer can hold only the
+// last items remain, the same as after calling Append() in a loop
+// If the batch eviction handler is set AppendAll calls it once for all
+// overwritten items, see SetBatchEvictionHandler()
+// Returns position of the next entry after the last item
+// The Reject policy drops every item which does not fit, AppendAll
+// adds the other items and returns -1 if any item was dropped. The
+// Block policy waits for a free slot for every item and releases the
+// lock while waiting, other calls can interleave with the items. The
+// Grow policy grows the buffer and keeps all items. See Policy
+func (cb *CyclicBuffer) AppendAll(items []interface{}) int {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	if cb.batchEvictionHandler != nil {
+		cb.evictedBatch = []interface{}{}
+		defer cb.flushEvicted()
+	}
+	rejected := false
+	for _, d := range items {
+		if cb.appendLocked(d) < 0 {
+			rejected = true
+		}
+	}
+	if rejected {
+		return -1
+	}
+	return cb.index
+}
+
+// AppendReuse adds the item returned by update to the cyclic buffer
+// update gets the oldest item which the Append is going to overwrite,
+// or nil if the buffer is not full. update can modify the old item in
+// place and return it, I use this to avoid an allocation for every
+// item of a large struct. The eviction handler gets the item returned
+// by update. AppendReuse removes the old item from the derived state,
+// for example, GetByKey() and RollingSum(), before calling update
+// update shall not reuse the old item if the buffer uses SetDedup(),
+// SetUniqueKey() or AddAppendMiddleware(), in these cases the old
+// item can remain in the buffer
+// update is called while the buffer is locked, update shall not call
+// the buffer API
+// Returns position of the next entry
+func (cb *CyclicBuffer) AppendReuse(update func(old interface{}) interface{}) int {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	var old interface{}
+	if cb.full && cb.policy == Overwrite {
+		old = cb.data[cb.index]
+		// update can modify the old item, I remove the state derived
+		// from the old item, for example, RollingSum(), before update
+		cb.slotRemoved(cb.index)
+		cb.detached, cb.detachedSlot = true, cb.index
+	}
+	res := cb.appendLocked(update(old))
+	if cb.detached {
+		// The Append did not remove the old item, the old item remains
+		// in the buffer as update left it
+		cb.detached = false
+		cb.slotAdded(cb.detachedSlot)
+	}
+	return res
+}
+
+// appendLocked adds an item, the caller holds the mutex
+// Returns position of the next entry
+// Returns -1 if the Reject policy dropped the item, see Policy
+func (cb *CyclicBuffer) appendLocked(d interface{}) int {
+	if res := cb.push(d); res.rejected {
+		return -1
+	}
+	return cb.index
+}
+
+// pushed describes what push() did
+type pushed struct {
+	// stored is false if the item was dropped, for example, a duplicate
+	stored bool
+	// evicted is true if the oldest item was overwritten
+	evicted bool
+	old     interface{}
+	// filled is true if the item took the last free slot
+	filled bool
+	// rejected is true if the buffer is full and the policy is Reject
+	rejected bool
+}
+
+// push does the actual work, the caller holds the mutex
+func (cb *CyclicBuffer) push(d interface{}) pushed {
+	var res pushed
+	d, ok := cb.applyMiddlewares(d)
+	if !ok {
+		return res
+	}
+	for cb.policy == Block && cb.full {
+		cb.notFull.Wait()
+	}
+	if cb.size == 0 && cb.policy != Grow {
+		// Block would wait forever, a buffer of size 0 is never free
+		if cb.policy == Reject || cb.policy == Block {
+			res.rejected = true
+			return res
+		}
+		// There is no room, the item is lost immediately
+		cb.totalAppended++
+		cb.evicted(d)
+		return res
+	}
+	if cb.dedup != nil {
+		count := cb.len()
+		if count > 0 && cb.compare(cb.dedup, cb.data[cb.physical(count-1)], d) {
+			cb.repeats++
+			return res
+		}
+	}
+	if cb.globalDedup != nil {
+		count := cb.len()
+		for i := 0; i < count; i++ {
+			if cb.compare(cb.globalDedup, cb.data[cb.physical(i)], d) {
+				cb.repeats++
+				return res
+			}
+		}
+	}
+	if cb.full && cb.policy == Reject {
+		res.rejected = true
+		return res
+	}
+	before := cb.len()
+	if key, ok := cb.keyOf(cb.uniqueKey, d); ok {
+		count := cb.len()
+		for i := 0; i < count; i++ {
+			if k, ok := cb.keyOf(cb.uniqueKey, cb.data[cb.physical(i)]); ok && k == key {
+				cb.removeAt(i)
+				break
+			}
+		}
+	}
+	if cb.sizeOf != nil {
+		cb.makeRoom(cb.itemSize(d), 0)
+	}
+	if cb.policy == Grow && (cb.full || cb.size == 0) {
+		cb.relayout(grownSize(cb.size))
+	}
+	cb.record(Op{Kind: OpAppend, Value: d})
 	var index = cb.index
+	if cb.full {
+		res.evicted, res.old = true, cb.data[index]
+		cb.evicted(res.old)
+		cb.slotRemoved(index)
+	}
 	cb.data[index] = d
+	cb.seq = cb.nextSeq()
+	cb.seqs[index] = cb.seq
+	cb.version++
+	cb.slotAdded(index)
+	cb.totalAppended++
+	res.stored = true
 	index++
 	if index >= cb.size {
 		index = 0
+		cb.wraps++
+	}
+	if cb.full {
+		// The oldest item is overwritten
+		cb.start = index
+	} else if index == cb.start {
 		cb.full = true
+		res.filled = true
 	}
 	cb.index = index
-	return index
+	cb.notEmpty.Broadcast()
+	if res.evicted {
+		cb.observe(EventEvict)
+	}
+	cb.observe(EventAppend)
+	cb.checkWatermark(before)
+	cb.fanOut(d)
+	return res
 }
 
-// AppendSafe is a thread safe API
-func (cb *CyclicBuffer) AppendSafe(d interface{}) int {
+// applyMiddlewares returns the item processed by the middlewares or
+// false if a middleware dropped the item, see AddAppendMiddleware()
+// The caller holds the mutex
+func (cb *CyclicBuffer) applyMiddlewares(d interface{}) (interface{}, bool) {
+	for _, m := range cb.middlewares {
+		next, ok := d, true
+		// If the middleware panics the item remains unchanged
+		cb.call(func() { next, ok = m(d) })
+		if !ok {
+			return nil, false
+		}
+		d = next
+	}
+	return d, true
+}
+
+// removeAt removes the item at the logical position i
+// The newer items move one slot towards the oldest
+// The caller holds the mutex and checks the range
+func (cb *CyclicBuffer) removeAt(i int) {
+	count := cb.len()
+	for j := i; j < count-1; j++ {
+		to, from := cb.physical(j), cb.physical(j+1)
+		cb.data[to] = cb.data[from]
+		cb.seqs[to] = cb.seqs[from]
+	}
+	cb.index = cb.physical(count - 1)
+	cb.data[cb.index] = nil
+	cb.full = false
+	cb.version++
+	cb.notFull.Broadcast()
+	cb.rebuild()
+}
+
+// slotAdded updates the state derived from the items, see SetKeyFunc()
+// and SetRollingSum(), after Append stores an item in the slot
+// The caller holds the mutex
+func (cb *CyclicBuffer) slotAdded(slot int) {
+	cb.keyIndexAdd(slot)
+	cb.rollingAdd(slot)
+	if cb.sizeOf != nil {
+		cb.byteLen += cb.itemSize(cb.data[slot])
+	}
+}
+
+// slotRemoved updates the state derived from the items before an item
+// leaves the slot. The caller holds the mutex
+// AppendReuse removes the state of the old item before the update, the
+// state of the slot is removed only once
+func (cb *CyclicBuffer) slotRemoved(slot int) {
+	if cb.detached && cb.detachedSlot == slot {
+		cb.detached = false
+		return
+	}
+	cb.keyIndexRemove(slot)
+	cb.rollingRemove(slot)
+	if cb.sizeOf != nil {
+		cb.byteLen -= cb.itemSize(cb.data[slot])
+	}
+}
+
+// rebuild computes the state derived from the items from scratch, I
+// call it after the items move to other slots. The caller holds the mutex
+func (cb *CyclicBuffer) rebuild() {
+	cb.detached = false
+	cb.keyIndexRebuild()
+	cb.rollingRebuild()
+	cb.byteLenRebuild()
+}
+
+// Compact removes the nil items from the buffer, the order of the
+// other items does not change. Returns the number of removed items
+func (cb *CyclicBuffer) Compact() int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.record(Op{Kind: OpCompact})
+	count := cb.len()
+	kept := 0
+	for i := 0; i < count; i++ {
+		from := cb.physical(i)
+		if cb.data[from] == nil {
+			continue
+		}
+		to := cb.physical(kept)
+		cb.data[to], cb.seqs[to] = cb.data[from], cb.seqs[from]
+		kept++
+	}
+	if kept == count {
+		return 0
+	}
+	for i := kept; i < count; i++ {
+		cb.data[cb.physical(i)] = nil
+	}
+	cb.setLayout(cb.size, cb.start, kept)
+	cb.rebuild()
+	return count - kept
+}
+
+// Clear removes all items from the buffer
+// The allocated memory is reused, the stored references are
+// released so the GC can collect them
+func (cb *CyclicBuffer) Clear() {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
-	return cb.Append(d)
+	cb.reset()
+}
+
+// reset is Clear() for callers holding the mutex
+// Clear(), Drain() and the like release the references via reset
+func (cb *CyclicBuffer) reset() {
+	cb.record(Op{Kind: OpClear})
+	for i := range cb.data {
+		cb.data[i] = nil
+	}
+	cb.index = 0
+	cb.start = 0
+	cb.full = false
+	cb.version++
+	cb.notFull.Broadcast()
+	cb.rebuild()
+}
+
+// Prefill writes the value to all free slots. The stored items, Len()
+// and Get() do not change. I call Prefill() at start to touch the
+// memory of a large buffer before the first Append
+func (cb *CyclicBuffer) Prefill(value interface{}) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	for i := cb.len(); i < cb.size; i++ {
+		cb.data[cb.physical(i)] = value
+	}
+	cb.prefill = value
+}
+
+// Resize changes the capacity of the buffer
+// The newest items which fit the new size are kept
+func (cb *CyclicBuffer) Resize(newSize int) error {
+	if newSize < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrInvalidSize, newSize)
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.relayout(newSize)
+	return nil
+}
+
+// Grow increases the capacity of the buffer by additional items
+// Grow never drops items
+func (cb *CyclicBuffer) Grow(additional int) error {
+	if additional < 0 {
+		return fmt.Errorf("%w: negative growth %d", ErrInvalidSize, additional)
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.relayout(cb.size + additional)
+	return nil
+}
+
+// grownSize returns the size of a full buffer with the Grow policy
+func grownSize(size int) int {
+	if size == 0 {
+		return 1
+	}
+	return 2 * size
+}
+
+// relayout allocates the data and moves the newest items which fit
+// the size to the beginning of the data. The caller holds the mutex
+func (cb *CyclicBuffer) relayout(size int) {
+	cb.record(Op{Kind: OpResize, Size: size})
+	count := cb.len()
+	skip := 0
+	if count > size {
+		skip = count - size
+	}
+	data := make([]interface{}, size)
+	seqs := make([]uint64, size)
+	for i := skip; i < count; i++ {
+		index := cb.physical(i)
+		data[i-skip] = cb.data[index]
+		seqs[i-skip] = cb.seqs[index]
+	}
+	cb.data, cb.seqs = data, seqs
+	cb.setLayout(size, 0, count-skip)
+	cb.rebuild()
+}
+
+// load allocates the data and copies the items, oldest first
+// The items get new sequence numbers
+// The caller holds the mutex and ensures that the items fit the size
+func (cb *CyclicBuffer) load(size int, items []interface{}) {
+	cb.loadAt(size, 0, items)
+}
+
+// loadAt is load() where the oldest item is stored in the slot start
+// The caller ensures that start is in the range of the size
+func (cb *CyclicBuffer) loadAt(size int, start int, items []interface{}) {
+	if cb.recording {
+		cb.record(Op{Kind: OpLoad, Size: size, Index: start, Value: append([]interface{}{}, items...)})
+	}
+	cb.data = make([]interface{}, size)
+	cb.seqs = make([]uint64, size)
+	cb.setLayout(size, start, len(items))
+	for i, d := range items {
+		index := cb.physical(i)
+		cb.seq = cb.nextSeq()
+		cb.data[index] = d
+		cb.seqs[index] = cb.seq
+	}
+	cb.rebuild()
+}
+
+// setLayout sets the state for count items starting at the slot start
+func (cb *CyclicBuffer) setLayout(size int, start int, count int) {
+	cb.size = size
+	cb.start = start
+	cb.full = (size > 0) && (count == size)
+	cb.index = start
+	if size > 0 {
+		cb.index = (start + count) % size
+	}
+	cb.version++
+	cb.notEmpty.Broadcast()
+	cb.notFull.Broadcast()
 }
 
 // Iterator object supporting loops
+// The iterator keeps a copy of the items, Append() does not affect
+// an existing iterator
 type Iterator struct {
 	index int
 	count int
-	cb    *CyclicBuffer
+	// data is the snapshot of the buffer, oldest item first
+	data []interface{}
+	// reverse iterators move from the newest item to the oldest
+	reverse bool
+	// initial state for Reset()
+	firstIndex int
+	firstCount int
+	// seqs are the sequence numbers of the items in the snapshot of
+	// cb, see Stale()
+	cb   *CyclicBuffer
+	seqs []uint64
 }
 
 // CreateIterator returns a new iterator
 // I want to call the user supplied callback and thread safety
 // in the Range() See, for example, sync.Map API
+// The iterator of a nil buffer is empty
 func (cb *CyclicBuffer) CreateIterator() *Iterator {
+	if cb == nil {
+		return newIterator(nil)
+	}
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	it := newIterator(cb.get())
+	cb.track(it, 0)
+	return it
+}
+
+// CreateTailIterator returns a new iterator over the newest n items,
+// oldest first. If n exceeds Len() the iterator returns all items
+func (cb *CyclicBuffer) CreateTailIterator(n int) *Iterator {
+	if cb == nil {
+		return newIterator(nil)
+	}
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	n, _ = cb.clampN(n)
+	it := newIterator(cb.copyRange(count-n, count))
+	cb.track(it, count-n)
+	return it
+}
+
+// newIterator returns an iterator over the items, oldest first
+func newIterator(data []interface{}) *Iterator {
 	var it Iterator
-	it.cb = cb
-	if cb.full {
-		it.index = cb.index
-		it.count = cb.size
-	} else {
-		it.index = 0
-		it.count = cb.index
+	it.data = data
+	it.index = 0
+	it.count = len(it.data)
+	it.firstIndex = it.index
+	it.firstCount = it.count
+	return &it
+}
+
+// CreateReverseIterator returns a new iterator which starts from
+// the newest item. The iterator of a nil buffer is empty
+func (cb *CyclicBuffer) CreateReverseIterator() *Iterator {
+	if cb == nil {
+		return newIterator(nil)
 	}
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	var it Iterator
+	it.data = cb.get()
+	it.reverse = true
+	it.index = len(it.data) - 1
+	it.count = len(it.data)
+	it.firstIndex = it.index
+	it.firstCount = it.count
+	cb.track(&it, 0)
 	return &it
 }
 
+// track keeps the sequence numbers of the items in the iterator, the
+// first item of the iterator is at the logical position from
+// The caller holds the mutex
+func (cb *CyclicBuffer) track(it *Iterator, from int) {
+	it.cb = cb
+	it.seqs = make([]uint64, 0, len(it.data))
+	count := cb.len()
+	for i := from; i < count; i++ {
+		it.seqs = append(it.seqs, cb.seqs[cb.physical(i)])
+	}
+}
+
 // CreateIterator is backward compatible API
+// The iterator of a nil buffer is empty
 func CreateIterator(cb *CyclicBuffer) *Iterator {
 	return cb.CreateIterator()
 }
 
 // Value returns item from the iterator
+// Call Value() only if Next() returns true, see also ValueOK()
+// Value returns nil if there are no more items
 func (it *Iterator) Value() interface{} {
-	value := it.cb.data[it.index]
-	it.index++
-	if it.index >= it.cb.size {
-		it.index = 0
+	if it.count <= 0 {
+		return nil
+	}
+	value := it.data[it.index]
+	if it.reverse {
+		it.index--
+	} else {
+		it.index++
 	}
 	it.count--
 	return value
 }
 
+// ValueOK returns item from the iterator
+// Returns false if there are no more items
+func (it *Iterator) ValueOK() (interface{}, bool) {
+	if it.count <= 0 {
+		return nil, false
+	}
+	return it.Value(), true
+}
+
+// Reset rewinds the iterator to the state it had when created
+func (it *Iterator) Reset() {
+	it.index = it.firstIndex
+	it.count = it.firstCount
+}
+
+// Seek moves the iterator to the logical position i, where 0 is
+// the oldest item in the iterator. The next Value() returns the item
+// at the position i
+func (it *Iterator) Seek(i int) error {
+	if i < 0 || i >= len(it.data) {
+		return fmt.Errorf("%w: position %d is out of [0, %d)", ErrOutOfRange, i, len(it.data))
+	}
+	it.index = i
+	if it.reverse {
+		it.count = i + 1
+	} else {
+		it.count = len(it.data) - i
+	}
+	return nil
+}
+
+// Remaining returns the number of items the iterator did not return yet
+func (it *Iterator) Remaining() int {
+	return it.count
+}
+
 // Next returns true if there anything else
 func (it *Iterator) Next() bool {
 	return (it.count > 0)
 }
 
+// Stale returns true if the item which the next Value() returns is
+// not in the buffer anymore, for example, Append has overwritten it
+// The iterator keeps a snapshot and Value() returns the item anyway
+// Stale returns false if there are no more items
+func (it *Iterator) Stale() bool {
+	if it.cb == nil || it.count <= 0 {
+		return false
+	}
+	it.cb.mutex.RLock()
+	defer it.cb.mutex.RUnlock()
+	return !it.cb.retains(it.seqs[it.index])
+}
+
+// retains returns true if the item with the sequence number seq is
+// in the buffer. The caller holds the mutex
+func (cb *CyclicBuffer) retains(seq uint64) bool {
+	count := cb.len()
+	// The sequence numbers ascend from the oldest item to the newest
+	i := sort.Search(count, func(i int) bool { return cb.seqs[cb.physical(i)] >= seq })
+	return i < count && cb.seqs[cb.physical(i)] == seq
+}
+
+// CyclicIterator loops over a snapshot of the buffer forever, from
+// the oldest item to the newest and again from the oldest
+// I use this iterator for round robin scheduling
+type CyclicIterator struct {
+	index int
+	data  []interface{}
+}
+
+// CreateCyclicIterator returns a new cyclic iterator
+// The iterator keeps a copy of the items, Append() does not affect
+// an existing iterator
+func (cb *CyclicBuffer) CreateCyclicIterator() *CyclicIterator {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return &CyclicIterator{data: cb.get()}
+}
+
+// Next returns true if the snapshot is not empty
+func (it *CyclicIterator) Next() bool {
+	return len(it.data) > 0
+}
+
+// Value returns the next item
+// Value returns nil if the snapshot is empty
+func (it *CyclicIterator) Value() interface{} {
+	if len(it.data) == 0 {
+		return nil
+	}
+	value := it.data[it.index]
+	it.index = (it.index + 1) % len(it.data)
+	return value
+}
+
+// WindowIterator loops over the sliding windows of a snapshot of the
+// buffer, see CreateWindowIterator()
+type WindowIterator struct {
+	index      int
+	windowSize int
+	step       int
+	data       []interface{}
+}
+
+// CreateWindowIterator returns an iterator which yields the windows of
+// windowSize consecutive items, oldest first. Every window starts step
+// items after the previous window, the windows overlap if step is less
+// than windowSize. The iterator stops when there are less than
+// windowSize items left. If windowSize exceeds Len() or is less than 1
+// there are no windows. A step less than 1 is 1
+// The iterator keeps a copy of the items, Append() does not affect
+// an existing iterator
+func (cb *CyclicBuffer) CreateWindowIterator(windowSize, step int) *WindowIterator {
+	if step < 1 {
+		step = 1
+	}
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return &WindowIterator{windowSize: windowSize, step: step, data: cb.get()}
+}
+
+// Next returns true if there is a full window
+func (it *WindowIterator) Next() bool {
+	return it.windowSize > 0 && it.index+it.windowSize <= len(it.data)
+}
+
+// Value returns the next window
+// The windows share the snapshot, the caller shall not modify the items
+// Value returns nil if there are no more windows
+func (it *WindowIterator) Value() []interface{} {
+	if !it.Next() {
+		return nil
+	}
+	end := it.index + it.windowSize
+	value := it.data[it.index:end:end]
+	it.index += it.step
+	return value
+}
+
 // Get returns a copy of the stored data
 // This is not a deep copy
 func (cb *CyclicBuffer) Get() []interface{} {
-	var index int
-	var count int
-	if cb.full {
-		index = cb.index
-		count = cb.size
-	} else {
-		index = 0
-		count = cb.index
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.get()
+}
+
+// GetReuse returns the stored data, oldest first, in a slice owned by
+// the buffer. The next GetReuse, in any goroutine, overwrites the
+// slice: the caller shall not keep or modify the slice after the next
+// GetReuse. GetReuse allocates only if Len() exceeds the length of the
+// previous GetReuse. I call GetReuse in a single poller, use Get()
+// if the items are shared
+func (cb *CyclicBuffer) GetReuse() []interface{} {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	count := cb.len()
+	if cap(cb.scratch) < count {
+		cb.scratch = make([]interface{}, count)
 	}
-	res := make([]interface{}, 0, count)
-	for i := 0; i < count; i++ {
-		d := cb.data[index]
+	res := cb.scratch[:count]
+	for i := range res {
+		res[i] = cb.data[cb.physical(i)]
+	}
+	// Do not keep the references to the removed items
+	for i := count; i < len(cb.scratch); i++ {
+		cb.scratch[i] = nil
+	}
+	cb.scratch = cb.scratch[:count]
+	return res
+}
+
+// Snapshot returns a deep copy of the stored data
+// The clone function is called for every item while the buffer is
+// locked, clone shall not call the buffer API
+// If clone is nil Snapshot is the same as Get()
+func (cb *CyclicBuffer) Snapshot(clone func(interface{}) interface{}) []interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if clone == nil {
+		return cb.get()
+	}
+	res := make([]interface{}, 0, cb.len())
+	cb.walk(func(_ int, d interface{}) bool {
+		res = append(res, clone(d))
+		return true
+	})
+	return res
+}
+
+// get is Get() for callers holding the mutex
+func (cb *CyclicBuffer) get() []interface{} {
+	res := make([]interface{}, 0, cb.len())
+	cb.walk(func(_ int, d interface{}) bool {
 		res = append(res, d)
+		return true
+	})
+	return res
+}
+
+// GetN returns a copy of the newest n items, oldest first
+// If n exceeds Len() all items are returned
+func (cb *CyclicBuffer) GetN(n int) []interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	if n > count {
+		n = count
+	}
+	if n < 0 {
+		n = 0
+	}
+	return cb.copyRange(count-n, count)
+}
+
+// First returns a copy of the oldest n items, oldest first
+// Returns false if the buffer holds less than n items, in this case
+// First returns all items
+func (cb *CyclicBuffer) First(n int) ([]interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	n, ok := cb.clampN(n)
+	return cb.copyRange(0, n), ok
+}
+
+// Last returns a copy of the newest n items, oldest first
+// Returns false if the buffer holds less than n items, in this case
+// Last returns all items
+func (cb *CyclicBuffer) Last(n int) ([]interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	n, ok := cb.clampN(n)
+	return cb.copyRange(count-n, count), ok
+}
+
+// clampN limits n to [0, len()], returns false if n exceeds len()
+func (cb *CyclicBuffer) clampN(n int) (int, bool) {
+	if n < 0 {
+		return 0, true
+	}
+	if count := cb.len(); n > count {
+		return count, false
+	}
+	return n, true
+}
+
+// CopyTo copies the newest items to dst, oldest first, and returns
+// the number of copied items. If dst is shorter than Len() the oldest
+// items are skipped. CopyTo does not allocate memory
+func (cb *CyclicBuffer) CopyTo(dst []interface{}) int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	n := len(dst)
+	if n > count {
+		n = count
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = cb.data[cb.physical(count-n+i)]
+	}
+	return n
+}
+
+// GetReversedInto copies the newest items to dst, newest first, and
+// returns the number of copied items. If dst is shorter than Len() the
+// oldest items are skipped. GetReversedInto does not allocate memory
+func (cb *CyclicBuffer) GetReversedInto(dst []interface{}) int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	n := len(dst)
+	if n > count {
+		n = count
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = cb.data[cb.physical(count-1-i)]
+	}
+	return n
+}
+
+// GetRange returns a copy of the items in the logical range [start, end),
+// where 0 is the oldest item
+func (cb *CyclicBuffer) GetRange(start, end int) ([]interface{}, error) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	if start < 0 || end > count || start > end {
+		return nil, fmt.Errorf("%w: range [%d, %d) is out of [0, %d)", ErrOutOfRange, start, end, count)
+	}
+	return cb.copyRange(start, end), nil
+}
+
+// copyRange returns a copy of the items in the logical range [from, to)
+// The caller holds the mutex and checks the range
+func (cb *CyclicBuffer) copyRange(from, to int) []interface{} {
+	res := make([]interface{}, 0, to-from)
+	for i := from; i < to; i++ {
+		res = append(res, cb.data[cb.physical(i)])
+	}
+	return res
+}
+
+// Range calls f sequentially for each item in the buffer, from
+// the oldest to the newest. If f returns false, Range stops the
+// iteration. See also sync.Map.Range()
+// Range holds the read lock while calling f, f shall not call the buffer API
+func (cb *CyclicBuffer) Range(f func(index int, value interface{}) bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	cb.walk(f)
+}
+
+// ForEach calls f for each item in the buffer, from the oldest
+// to the newest
+// ForEach holds the read lock while calling f, f shall not call the buffer API
+func (cb *CyclicBuffer) ForEach(f func(value interface{})) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	cb.walk(func(_ int, d interface{}) bool {
+		f(d)
+		return true
+	})
+}
+
+// ReadLocked calls f with the internal slots of the buffer without
+// copying the items. The item i, where 0 is the oldest item and
+// count-1 is the newest, is slots[(start+i)%len(slots)]
+// ReadLocked holds the read lock while calling f. f shall not modify
+// or keep the slice after the return and shall not call the buffer API
+func (cb *CyclicBuffer) ReadLocked(f func(slots []interface{}, start, count int)) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	f(cb.data, cb.start, cb.len())
+}
+
+// walk is Range() for callers holding the mutex
+func (cb *CyclicBuffer) walk(f func(index int, value interface{}) bool) {
+	index := cb.start
+	count := cb.len()
+	for i := 0; i < count; i++ {
+		if !f(i, cb.data[index]) {
+			return
+		}
 		index++
 		if index >= cb.size {
 			index = 0
 		}
 	}
-	return res
 }
 
-// GetData returns all items in the buffer
+// Newest returns the most recently added item
+// Returns false if the buffer is empty
+func (cb *CyclicBuffer) Newest() (interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.newest()
+}
+
+// newest is Newest() for callers holding the mutex
+func (cb *CyclicBuffer) newest() (interface{}, bool) {
+	if cb.len() == 0 {
+		return nil, false
+	}
+	// If index is 0 and the buffer is full the newest item is at size-1
+	index := (cb.index - 1 + cb.size) % cb.size
+	return cb.data[index], true
+}
+
+// Oldest returns the oldest item which is not overwritten yet
+// Returns false if the buffer is empty
+func (cb *CyclicBuffer) Oldest() (interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.len() == 0 {
+		return nil, false
+	}
+	return cb.data[cb.start], true
+}
+
+// Bounds returns the oldest and the newest items in one lock
+// Returns false if the buffer is empty
+func (cb *CyclicBuffer) Bounds() (oldest, newest interface{}, ok bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	newest, ok = cb.newest()
+	if !ok {
+		return nil, nil, false
+	}
+	return cb.data[cb.start], newest, true
+}
+
+// At returns the item at the logical position i, where 0 is the
+// oldest item and Len()-1 is the newest
+// Returns false if i is out of range
+func (cb *CyclicBuffer) At(i int) (interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if i < 0 || i >= cb.len() {
+		return nil, false
+	}
+	return cb.data[cb.physical(i)], true
+}
+
+// PhysicalIndex returns the slot in AllSlots() of the item at the
+// logical position i, where 0 is the oldest item
+// Returns false if i is out of range
+func (cb *CyclicBuffer) PhysicalIndex(i int) (int, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if i < 0 || i >= cb.len() {
+		return 0, false
+	}
+	return cb.physical(i), true
+}
+
+// Set replaces the item at the logical position i, where 0 is the
+// oldest item and Len()-1 is the newest. The order of the items does
+// not change. Returns ErrEmpty if the buffer is empty, ErrOutOfRange
+// if i is out of range, ErrFull if the item does not fit the limit
+// of NewSizeBounded()
+func (cb *CyclicBuffer) Set(i int, d interface{}) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	count := cb.len()
+	if count == 0 {
+		return fmt.Errorf("%w: index %d", ErrEmpty, i)
+	}
+	if i < 0 || i >= count {
+		return fmt.Errorf("%w: index %d is out of [0, %d)", ErrOutOfRange, i, count)
+	}
+	slot := cb.physical(i)
+	if cb.sizeOf != nil && cb.byteLen-cb.itemSize(cb.data[slot])+cb.itemSize(d) > cb.byteLimit {
+		return fmt.Errorf("%w: the item does not fit %d bytes", ErrFull, cb.byteLimit)
+	}
+	cb.record(Op{Kind: OpSet, Index: i, Value: d})
+	cb.data[slot] = d
+	cb.version++
+	cb.rebuild()
+	return nil
+}
+
+// ReplaceNewest replaces the newest item and returns true. If the
+// buffer is empty ReplaceNewest appends the item and returns false
+// I use ReplaceNewest for the telemetry where only the latest sample
+// of an interval matters. The replaced item gets a new sequence number,
+// see AppendSince()
+// The middlewares process the item, the observer gets EventReplace and
+// the subscribers get the item. If a middleware drops the item
+// ReplaceNewest does not modify the buffer and returns false. The dedup
+// does not apply. ReplaceNewest evicts only the oldest items of a
+// buffer created by NewSizeBounded()
+func (cb *CyclicBuffer) ReplaceNewest(d interface{}) bool {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	count := cb.len()
+	if count == 0 {
+		cb.appendLocked(d)
+		return false
+	}
+	d, ok := cb.applyMiddlewares(d)
+	if !ok {
+		return false
+	}
+	index := cb.physical(count - 1)
+	oldKey, hadKey := cb.keyOf(cb.keyFunc, cb.data[index])
+	cb.slotRemoved(index)
+	if cb.sizeOf != nil {
+		// The replaced item does not count, the oldest items make room
+		cb.makeRoom(cb.itemSize(d), 1)
+	}
+	cb.record(Op{Kind: OpReplaceNewest, Value: d})
+	cb.data[index] = d
+	cb.seq = cb.nextSeq()
+	cb.seqs[index] = cb.seq
+	cb.version++
+	cb.slotAdded(index)
+	if key, _ := cb.keyOf(cb.keyFunc, d); hadKey && key != oldKey {
+		// An older item can have the old key, see GetByKey()
+		cb.keyIndexRebuild()
+	}
+	cb.observe(EventReplace)
+	cb.fanOut(d)
+	return true
+}
+
+// physical translates a logical position to a slot in cb.data
+// The caller holds the mutex and checks the range
+func (cb *CyclicBuffer) physical(i int) int {
+	return (cb.start + i) % cb.size
+}
+
+// ToChannel returns a closed channel containing a snapshot of the
+// stored data, from the oldest to the newest
+// The channel is buffered, the caller does not have to drain it
+func (cb *CyclicBuffer) ToChannel() <-chan interface{} {
+	cb.mutex.RLock()
+	data := cb.get()
+	cb.mutex.RUnlock()
+	ch := make(chan interface{}, len(data))
+	for _, d := range data {
+		ch <- d
+	}
+	close(ch)
+	return ch
+}
+
+// String implements fmt.Stringer
+// For example "CyclicBuffer(len=3/cap=5)[a b c]"
+func (cb *CyclicBuffer) String() string {
+	cb.mutex.RLock()
+	data := cb.get()
+	size := cb.size
+	cb.mutex.RUnlock()
+	return fmt.Sprintf("CyclicBuffer(len=%d/cap=%d)%v", len(data), size, data)
+}
+
+// GetData returns a copy of all slots in the buffer
+//
+// Deprecated: use Get() for the stored items or AllSlots()
 func (cb *CyclicBuffer) GetData() []interface{} {
-	return cb.data
+	return cb.AllSlots()
+}
+
+// AllSlots returns a copy of all Cap() slots in the buffer, including
+// the slots which were not written yet, in the order of the slots
+// I use AllSlots() for diagnostics. Use Get() for the stored items
+func (cb *CyclicBuffer) AllSlots() []interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	res := make([]interface{}, len(cb.data))
+	copy(res, cb.data)
+	return res
+}
+
+// GetPhysical returns a copy of the stored items in the order of the
+// slots, see AllSlots(). The free slots are skipped
+// Get() returns the items from the oldest to the newest. GetPhysical()
+// is cheaper for the caller which does not care about the order
+func (cb *CyclicBuffer) GetPhysical() []interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	count := cb.len()
+	res := make([]interface{}, 0, count)
+	for i, d := range cb.data {
+		if (i-cb.start+cb.size)%cb.size < count {
+			res = append(res, d)
+		}
+	}
+	return res
 }
@@ -0,0 +1,1215 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendSafeNoDeadlock(t *testing.T) {
+	cb := New(8)
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					cb.AppendSafe(i)
+				}
+			}()
+		}
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	if cb.Len() != 8 {
+		t.Fatal(cb.Len())
+	}
+}
+
+func TestLen(t *testing.T) {
+	cb := New(5)
+	if cb.Len() != 0 {
+		t.Fatal(cb.Len())
+	}
+	for i := 0; i < 8; i++ {
+		cb.Append(i)
+	}
+	if cb.Len() != 5 {
+		t.Fatal(cb.Len())
+	}
+}
+
+func TestCap(t *testing.T) {
+	cb := New(5)
+	for i := 0; i < 23; i++ {
+		cb.Append(i)
+	}
+	if cb.Cap() != 5 {
+		t.Fatal(cb.Cap())
+	}
+}
+
+func TestClear(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	cb.Clear()
+	if !cb.Empty() || cb.Len() != 0 || cb.Cap() != 3 {
+		t.Fatal("not empty")
+	}
+	cb.Append(7)
+	if g := cb.Get(); len(g) != 1 || g[0] != 7 {
+		t.Fatal(g)
+	}
+}
+
+func TestRange(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	var got []interface{}
+	cb.Range(func(i int, v interface{}) bool { got = append(got, v); return true })
+	if len(got) != 3 || got[0] != 2 || got[2] != 4 {
+		t.Fatal(got)
+	}
+	n := 0
+	cb.Range(func(i int, v interface{}) bool { n++; return i < 1 })
+	if n != 2 {
+		t.Fatal(n)
+	}
+}
+
+func TestNewest(t *testing.T) {
+	cb := New(3)
+	if _, ok := cb.Newest(); ok {
+		t.Fatal()
+	}
+	cb.Append(1)
+	if v, _ := cb.Newest(); v != 1 {
+		t.Fatal(v)
+	}
+	cb.Append(2)
+	cb.Append(3)
+	if v, _ := cb.Newest(); v != 3 {
+		t.Fatal(v)
+	}
+	cb.Append(4)
+	if v, _ := cb.Newest(); v != 4 {
+		t.Fatal(v)
+	}
+}
+
+func TestOldest(t *testing.T) {
+	cb := New(3)
+	if _, ok := cb.Oldest(); ok {
+		t.Fatal()
+	}
+	cb.Append(1)
+	cb.Append(2)
+	if v, _ := cb.Oldest(); v != 1 {
+		t.Fatal(v)
+	}
+	cb.Append(3)
+	cb.Append(4)
+	if v, _ := cb.Oldest(); v != 2 {
+		t.Fatal(v)
+	}
+}
+
+func TestAt(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	for i := 0; i < 4; i++ {
+		if v, ok := cb.At(i); !ok || v != i+2 {
+			t.Fatal(i, v)
+		}
+	}
+	if _, ok := cb.At(-1); ok {
+		t.Fatal()
+	}
+	if _, ok := cb.At(4); ok {
+		t.Fatal()
+	}
+}
+
+func TestAppendAll(t *testing.T) {
+	for _, n := range []int{2, 4, 7} {
+		a, b := New(4), New(4)
+		items := []interface{}{}
+		for i := 0; i < n; i++ {
+			items = append(items, i)
+			b.Append(i)
+		}
+		a.Append(-1)
+		b2 := New(4)
+		b2.Append(-1)
+		for _, x := range items {
+			b2.Append(x)
+		}
+		r := a.AppendAll(items)
+		if r != b2.index || len(a.Get()) != len(b2.Get()) || a.Len() != b2.Len() {
+			t.Fatal(n)
+		}
+		for i, v := range a.Get() {
+			if b2.Get()[i] != v {
+				t.Fatal(n)
+			}
+		}
+	}
+}
+
+func TestNewFromSlice(t *testing.T) {
+	cb := NewFromSlice([]interface{}{1, 2, 3})
+	if g := cb.Get(); len(g) != 3 || g[0] != 1 || g[2] != 3 {
+		t.Fatal(g)
+	}
+	cb.Append(4)
+	if g := cb.Get(); g[0] != 2 || g[2] != 4 {
+		t.Fatal(g)
+	}
+	e := NewFromSlice(nil)
+	if e.Len() != 0 || len(e.Get()) != 0 {
+		t.Fatal()
+	}
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	cb := New(100)
+	for i := 0; i < 150; i++ {
+		cb.Append(i)
+	}
+	// Two readers inside Range at the same time would deadlock with a Mutex
+	in := make(chan struct{})
+	out := make(chan struct{})
+	go cb.Range(func(int, interface{}) bool { close(in); <-out; return false })
+	<-in
+	if cb.Len() != 100 {
+		t.Fatal()
+	}
+	close(out)
+}
+
+func BenchmarkParallelGet(b *testing.B) {
+	cb := New(100)
+	for i := 0; i < 100; i++ {
+		cb.Append(i)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cb.Get()
+		}
+	})
+}
+
+// Every goroutine appends to its own buffer, the buffers do not share
+// the sequence numbers
+func BenchmarkParallelAppend(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		cb := New(100)
+		for i := 0; pb.Next(); i++ {
+			cb.Append(i)
+		}
+	})
+}
+
+func TestGetDataCopy(t *testing.T) {
+	cb := New(3)
+	cb.Append(1)
+	d := cb.GetData()
+	d[0] = 5
+	if cb.Get()[0] != 1 || len(d) != 3 {
+		t.Fatal()
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	type item struct{ v int }
+	cb := New(2)
+	p := &item{1}
+	cb.Append(p)
+	s := cb.Snapshot(func(d interface{}) interface{} { c := *d.(*item); return &c })
+	p.v = 2
+	if s[0].(*item).v != 1 {
+		t.Fatal()
+	}
+	if cb.Snapshot(nil)[0].(*item).v != 2 {
+		t.Fatal()
+	}
+}
+
+func TestString(t *testing.T) {
+	cb := New(3)
+	if cb.String() != "CyclicBuffer(len=0/cap=3)[]" {
+		t.Fatal(cb.String())
+	}
+	cb.Append("a")
+	if cb.String() != "CyclicBuffer(len=1/cap=3)[a]" {
+		t.Fatal(cb.String())
+	}
+	cb.Append("b")
+	cb.Append("c")
+	cb.Append("d")
+	if cb.String() != "CyclicBuffer(len=3/cap=3)[b c d]" {
+		t.Fatal(cb.String())
+	}
+}
+
+func TestResize(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	cb.Resize(5)
+	if g := cb.Get(); len(g) != 3 || g[0] != 2 || cb.Cap() != 5 {
+		t.Fatal(g)
+	}
+	cb.Append(5)
+	cb.Resize(2)
+	if g := cb.Get(); len(g) != 2 || g[0] != 4 || g[1] != 5 || cb.Len() != 2 {
+		t.Fatal(g)
+	}
+	cb.Append(6)
+	if g := cb.Get(); g[0] != 5 || g[1] != 6 {
+		t.Fatal(g)
+	}
+	cb.Resize(0)
+	if cb.Len() != 0 || cb.Cap() != 0 {
+		t.Fatal()
+	}
+	if cb.Resize(-1) == nil {
+		t.Fatal()
+	}
+}
+
+func drain(it *Iterator) []interface{} {
+	res := []interface{}{}
+	for it.Next() {
+		res = append(res, it.Value())
+	}
+	return res
+}
+
+func TestIteratorReset(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	it := cb.CreateIterator()
+	a := drain(it)
+	it.Reset()
+	b := drain(it)
+	if !reflect.DeepEqual(a, b) || len(a) != 3 {
+		t.Fatal(a, b)
+	}
+}
+
+func TestReverseIterator(t *testing.T) {
+	for n := 0; n < 8; n++ {
+		cb := New(4)
+		for i := 0; i < n; i++ {
+			cb.Append(i)
+		}
+		g := cb.Get()
+		r := drain(cb.CreateReverseIterator())
+		if len(r) != len(g) {
+			t.Fatal(n)
+		}
+		for i := range g {
+			if g[i] != r[len(r)-1-i] {
+				t.Fatal(n, g, r)
+			}
+		}
+	}
+}
+
+func TestIteratorConcurrent(t *testing.T) {
+	cb := New(64)
+	for i := 0; i < 64; i++ {
+		cb.Append(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		for i := 64; i < 20000; i++ {
+			cb.Append(i)
+		}
+		close(done)
+	}()
+	for k := 0; k < 100; k++ {
+		it := cb.CreateIterator()
+		prev := -1
+		for it.Next() {
+			v := it.Value().(int)
+			if prev >= 0 && v != prev+1 {
+				t.Fatal(prev, v)
+			}
+			prev = v
+		}
+	}
+	<-done
+}
+
+func TestForEach(t *testing.T) {
+	cb := New(3)
+	b := NewBuffer[int](3)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+		b.Append(i)
+	}
+	s1, s2, s3 := 0, 0, 0
+	cb.ForEach(func(v interface{}) { s1 += v.(int) })
+	for _, v := range cb.Get() {
+		s2 += v.(int)
+	}
+	b.ForEach(func(v int) { s3 += v })
+	if s1 != s2 || s1 != 15 || s3 != 15 {
+		t.Fatal(s1, s2, s3)
+	}
+}
+
+func TestToChannel(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	ch := cb.ToChannel()
+	cb.Append(9)
+	var got []interface{}
+	for v := range ch {
+		got = append(got, v)
+	}
+	if len(got) != 3 || got[0] != 2 || got[2] != 4 {
+		t.Fatal(got)
+	}
+}
+
+func TestAppendIfNotFull(t *testing.T) {
+	cb := New(2)
+	if !cb.AppendIfNotFull(1) || !cb.AppendIfNotFull(2) || cb.AppendIfNotFull(3) {
+		t.Fatal()
+	}
+	if g := cb.Get(); g[0] != 1 || g[1] != 2 {
+		t.Fatal(g)
+	}
+}
+
+func TestGetN(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if g := cb.GetN(2); len(g) != 2 || g[0] != 4 || g[1] != 5 {
+		t.Fatal(g)
+	}
+	if g := cb.GetN(3); g[0] != 3 {
+		t.Fatal(g)
+	}
+	if g := cb.GetN(10); len(g) != 4 || g[0] != 2 {
+		t.Fatal(g)
+	}
+	if g := cb.GetN(0); g == nil || len(g) != 0 {
+		t.Fatal(g)
+	}
+	if g := cb.GetN(-1); len(g) != 0 {
+		t.Fatal(g)
+	}
+}
+
+func TestGetRange(t *testing.T) {
+	cb := New(5)
+	for i := 0; i < 8; i++ {
+		cb.Append(i)
+	}
+	// physical: [5 6 7 3 4], start=3
+	g, err := cb.GetRange(0, 2)
+	if err != nil || g[0] != 3 || g[1] != 4 {
+		t.Fatal(g)
+	}
+	g, _ = cb.GetRange(1, 4)
+	if g[0] != 4 || g[2] != 6 {
+		t.Fatal(g)
+	}
+	g, _ = cb.GetRange(3, 5)
+	if g[0] != 6 || g[1] != 7 {
+		t.Fatal(g)
+	}
+	for _, r := range [][2]int{{-1, 2}, {0, 6}, {3, 2}} {
+		if _, err := cb.GetRange(r[0], r[1]); err == nil {
+			t.Fatal(r)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	c := cb.Clone()
+	if !c.Equal(cb, nil) {
+		t.Fatal()
+	}
+	c.Append(10)
+	cb.Append(20)
+	if g := c.Get(); g[2] != 10 || g[0] != 2 {
+		t.Fatal(g)
+	}
+	if g := cb.Get(); g[2] != 20 {
+		t.Fatal(g)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	it := cb.CreateIterator()
+	if it.Seek(0) != nil || len(drain(it)) != 4 {
+		t.Fatal()
+	}
+	it.Seek(2)
+	if g := drain(it); len(g) != 2 || g[0] != 4 {
+		t.Fatal(g)
+	}
+	it.Seek(3)
+	if g := drain(it); len(g) != 1 || g[0] != 5 {
+		t.Fatal(g)
+	}
+	if it.Seek(4) == nil || it.Seek(-1) == nil {
+		t.Fatal()
+	}
+	r := cb.CreateReverseIterator()
+	r.Seek(1)
+	if g := drain(r); len(g) != 2 || g[0] != 3 || g[1] != 2 {
+		t.Fatal(g)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 3; i++ {
+		cb.Append(i)
+	}
+	it := cb.CreateIterator()
+	it.Value()
+	if it.Remaining() != 2 {
+		t.Fatal()
+	}
+	drain(it)
+	if it.Remaining() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestNewChecked(t *testing.T) {
+	if _, err := NewChecked(-1); err == nil {
+		t.Fatal()
+	}
+	cb, err := NewChecked(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb.Append(1)
+	if cb.Len() != 0 || !cb.Empty() || len(cb.Get()) != 0 {
+		t.Fatal()
+	}
+	if _, ok := cb.Newest(); ok {
+		t.Fatal()
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal()
+			}
+		}()
+		New(-1)
+	}()
+	cb, _ = NewChecked(2)
+	cb.Append(1)
+	if cb.Len() != 1 {
+		t.Fatal()
+	}
+}
+
+func TestAppendReturningEvicted(t *testing.T) {
+	cb := New(2)
+	for i := 0; i < 2; i++ {
+		if e, ok := cb.AppendReturningEvicted(i); ok || e != nil {
+			t.Fatal()
+		}
+	}
+	if e, ok := cb.AppendReturningEvicted(2); !ok || e != 0 {
+		t.Fatal(e)
+	}
+	if e, ok := cb.AppendReturningEvicted(3); !ok || e != 1 {
+		t.Fatal(e)
+	}
+}
+
+func TestFull(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 3; i++ {
+		if cb.Full() {
+			t.Fatal(i)
+		}
+		cb.Append(i)
+	}
+	if !cb.Full() {
+		t.Fatal()
+	}
+	cb.Pop()
+	if cb.Full() {
+		t.Fatal()
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	d := make([]interface{}, 2)
+	if cb.CopyTo(d) != 2 || d[0] != 4 || d[1] != 5 {
+		t.Fatal(d)
+	}
+	d = make([]interface{}, 4)
+	if cb.CopyTo(d) != 4 || d[0] != 2 {
+		t.Fatal(d)
+	}
+	d = make([]interface{}, 6)
+	if cb.CopyTo(d) != 4 || d[0] != 2 || d[3] != 5 || d[4] != nil {
+		t.Fatal(d)
+	}
+}
+
+func TestValueOK(t *testing.T) {
+	cb := New(2)
+	cb.Append(1)
+	it := cb.CreateIterator()
+	if v, ok := it.ValueOK(); !ok || v != 1 {
+		t.Fatal()
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := it.ValueOK(); ok {
+			t.Fatal()
+		}
+	}
+	if it.Remaining() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestGrow(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	cb.Grow(2)
+	if cb.Len() != 3 || cb.Cap() != 5 || cb.Full() {
+		t.Fatal()
+	}
+	cb.Append(4)
+	cb.Append(5)
+	if g := cb.Get(); len(g) != 5 || g[0] != 1 || g[4] != 5 {
+		t.Fatal(g)
+	}
+	if cb.Grow(-1) == nil {
+		t.Fatal()
+	}
+}
+
+func TestCompareAndAppend(t *testing.T) {
+	cb := New(3)
+	if cb.CompareAndAppend(1, 2, nil) {
+		t.Fatal()
+	}
+	if !cb.CompareAndAppend(nil, 1, nil) {
+		t.Fatal()
+	}
+	if cb.CompareAndAppend(5, 2, nil) || cb.Len() != 1 {
+		t.Fatal()
+	}
+	if !cb.CompareAndAppend(1, 2, nil) || cb.Len() != 2 {
+		t.Fatal()
+	}
+}
+
+func TestZeroSizeIteration(t *testing.T) {
+	for _, cb := range []*CyclicBuffer{New(0), New(3)} {
+		cb.Append(1)
+		cb.Pop()
+		for _, it := range []*Iterator{cb.CreateIterator(), cb.CreateReverseIterator()} {
+			if it.Next() || it.Value() != nil {
+				t.Fatal()
+			}
+		}
+		if g := cb.Get(); g == nil || len(g) != 0 {
+			t.Fatal()
+		}
+	}
+	b := NewBuffer[int](0)
+	b.Append(1)
+	it := b.CreateIterator()
+	if it.Next() || it.Value() != 0 || len(b.Get()) != 0 {
+		t.Fatal()
+	}
+	var zit Iterator
+	if zit.Next() || zit.Value() != nil {
+		t.Fatal()
+	}
+}
+
+func TestAppendChecked(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 3; i++ {
+		if _, o := cb.AppendChecked(i); o {
+			t.Fatal()
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if n, o := cb.AppendChecked(i); !o || n != (i+1)%3 {
+			t.Fatal()
+		}
+	}
+}
+
+func TestAllSlots(t *testing.T) {
+	cb := New(3)
+	cb.Append(1)
+	d := cb.AllSlots()
+	d[0] = 5
+	if len(d) != 3 || cb.Get()[0] != 1 {
+		t.Fatal()
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	cb := New(4)
+	cb.Append(0)
+	cb.Append(1)
+	if r, ok := cb.First(5); ok || len(r) != 2 {
+		t.Fatal(r, ok)
+	}
+	if r, ok := cb.Last(0); !ok || len(r) != 0 {
+		t.Fatal(r, ok)
+	}
+	if r, ok := cb.Last(-1); !ok || len(r) != 0 {
+		t.Fatal(r, ok)
+	}
+	for i := 2; i < 6; i++ {
+		cb.Append(i)
+	}
+	if r, ok := cb.First(3); !ok || len(r) != 3 || r[0] != 2 || r[2] != 4 {
+		t.Fatal(r, ok)
+	}
+	if r, ok := cb.Last(3); !ok || len(r) != 3 || r[0] != 3 || r[2] != 5 {
+		t.Fatal(r, ok)
+	}
+	if r, ok := cb.Last(4); !ok || len(r) != 4 || r[0] != 2 {
+		t.Fatal(r, ok)
+	}
+}
+
+func TestSet(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	for i, v := range []interface{}{"a", "b", "c"} {
+		pos := []int{0, 1, 3}[i]
+		if err := cb.Set(pos, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := cb.Get()
+	if g[0] != "a" || g[1] != "b" || g[2] != 4 || g[3] != "c" || !cb.Full() {
+		t.Fatal(g)
+	}
+	if cb.Set(4, 0) == nil || cb.Set(-1, 0) == nil {
+		t.Fatal()
+	}
+	cb.Append(6)
+	if g := cb.Get(); g[0] != "b" || g[3] != 6 {
+		t.Fatal(g)
+	}
+}
+
+func TestFillRatio(t *testing.T) {
+	cb := New(4)
+	if cb.FillRatio() != 0 || New(0).FillRatio() != 0 {
+		t.Fatal()
+	}
+	cb.Append(1)
+	cb.Append(2)
+	if cb.FillRatio() != 0.5 {
+		t.Fatal(cb.FillRatio())
+	}
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	if cb.FillRatio() != 1 {
+		t.Fatal(cb.FillRatio())
+	}
+}
+
+func TestNilIterator(t *testing.T) {
+	var cb *CyclicBuffer
+	for _, it := range []*Iterator{CreateIterator(nil), cb.CreateIterator(), cb.CreateReverseIterator()} {
+		if it.Next() || it.Value() != nil || it.Remaining() != 0 {
+			t.Fatal()
+		}
+		it.Reset()
+	}
+}
+
+func TestGetReversedInto(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	dst := make([]interface{}, 6)
+	if n := cb.GetReversedInto(dst); n != 4 || dst[0] != 5 || dst[3] != 2 || dst[4] != nil {
+		t.Fatal(n, dst)
+	}
+	dst = make([]interface{}, 2)
+	if n := cb.GetReversedInto(dst); n != 2 || dst[0] != 5 || dst[1] != 4 {
+		t.Fatal(n, dst)
+	}
+	if n := New(2).GetReversedInto(dst); n != 0 {
+		t.Fatal(n)
+	}
+}
+
+func TestPhysicalIndex(t *testing.T) {
+	cb := New(4)
+	cb.Append(0)
+	cb.Append(1)
+	if p, ok := cb.PhysicalIndex(0); !ok || p != 0 {
+		t.Fatal(p)
+	}
+	if p, ok := cb.PhysicalIndex(1); !ok || p != 1 {
+		t.Fatal(p)
+	}
+	for i := 2; i < 7; i++ {
+		cb.Append(i)
+	}
+	slots := cb.AllSlots()
+	if p, ok := cb.PhysicalIndex(0); !ok || p != 3 || slots[p] != 3 {
+		t.Fatal(p)
+	}
+	if p, ok := cb.PhysicalIndex(3); !ok || p != 2 || slots[p] != 6 {
+		t.Fatal(p)
+	}
+	if _, ok := cb.PhysicalIndex(4); ok {
+		t.Fatal()
+	}
+}
+
+func TestBounds(t *testing.T) {
+	cb := New(3)
+	if _, _, ok := cb.Bounds(); ok {
+		t.Fatal()
+	}
+	cb.Append(0)
+	if o, n, ok := cb.Bounds(); !ok || o != 0 || n != 0 {
+		t.Fatal(o, n)
+	}
+	for i := 1; i < 5; i++ {
+		cb.Append(i)
+	}
+	if o, n, ok := cb.Bounds(); !ok || o != 2 || n != 4 {
+		t.Fatal(o, n)
+	}
+}
+
+func TestAppendReuse(t *testing.T) {
+	type rec struct{ v int }
+	cb := New(2)
+	var seen []interface{}
+	for i := 0; i < 4; i++ {
+		cb.AppendReuse(func(old interface{}) interface{} {
+			seen = append(seen, old)
+			if old == nil {
+				return &rec{v: i}
+			}
+			old.(*rec).v = i
+			return old
+		})
+	}
+	if seen[0] != nil || seen[1] != nil || seen[2].(*rec).v != 2 || seen[3].(*rec).v != 3 {
+		t.Fatal(seen)
+	}
+	g := cb.Get()
+	if g[0].(*rec).v != 2 || g[1].(*rec).v != 3 || g[0] != seen[2] {
+		t.Fatal(g)
+	}
+}
+
+func TestGetPhysical(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	p := cb.GetPhysical()
+	if len(p) != 4 || p[0] != 4 || p[1] != 5 || p[2] != 2 || p[3] != 3 {
+		t.Fatal(p)
+	}
+	cb.Pop()
+	p = cb.GetPhysical()
+	if len(p) != 3 || p[0] != 4 || p[1] != 5 || p[2] != 3 {
+		t.Fatal(p)
+	}
+	if len(New(0).GetPhysical()) != 0 {
+		t.Fatal()
+	}
+}
+
+func TestAppendDetectFull(t *testing.T) {
+	cb := New(3)
+	var fills []int
+	for i := 0; i < 7; i++ {
+		if _, filled := cb.AppendDetectFull(i); filled {
+			fills = append(fills, i)
+		}
+	}
+	if len(fills) != 1 || fills[0] != 2 {
+		t.Fatal(fills)
+	}
+	cb.Pop()
+	if _, filled := cb.AppendDetectFull(7); !filled {
+		t.Fatal()
+	}
+}
+
+func TestIteratorStale(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 3; i++ {
+		cb.Append(i)
+	}
+	it := cb.CreateIterator()
+	if it.Stale() {
+		t.Fatal()
+	}
+	cb.Append(3)
+	if !it.Stale() || it.Value() != 0 {
+		t.Fatal()
+	}
+	if it.Stale() || it.Value() != 1 {
+		t.Fatal()
+	}
+	cb.Pop()
+	cb.Pop()
+	if !it.Stale() {
+		t.Fatal()
+	}
+	rit := cb.CreateReverseIterator()
+	if rit.Stale() {
+		t.Fatal()
+	}
+	cb.Clear()
+	if !rit.Stale() {
+		t.Fatal()
+	}
+	rit.Value()
+	if rit.Next() || rit.Stale() {
+		t.Fatal()
+	}
+	if CreateIterator(nil).Stale() {
+		t.Fatal()
+	}
+}
+
+func TestPrefill(t *testing.T) {
+	cb := New(3)
+	cb.Append(1)
+	cb.Prefill(-1)
+	if cb.Len() != 1 || cb.Get()[0] != 1 {
+		t.Fatal(cb.Get())
+	}
+	if s := cb.AllSlots(); s[0] != 1 || s[1] != -1 || s[2] != -1 {
+		t.Fatal(s)
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cb.Append(2)
+	if g := cb.Get(); len(g) != 2 || g[1] != 2 || cb.AllSlots()[2] != -1 {
+		t.Fatal(g)
+	}
+}
+
+func TestTailIterator(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	for _, n := range []int{-1, 0, 1, 3, 4, 10} {
+		it := cb.CreateTailIterator(n)
+		var got []interface{}
+		for it.Next() {
+			got = append(got, it.Value())
+		}
+		want, _ := cb.Last(n)
+		if len(got) != len(want) {
+			t.Fatal(n, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatal(n, got, want)
+			}
+		}
+	}
+	it := cb.CreateTailIterator(2)
+	cb.Append(6)
+	cb.Append(7)
+	if it.Stale() {
+		t.Fatal()
+	}
+	cb.Append(8)
+	if !it.Stale() {
+		t.Fatal()
+	}
+}
+
+func TestCompact(t *testing.T) {
+	cb := New(6)
+	for _, d := range []interface{}{9, 9, nil, 0, nil, 1, nil, 2, nil} {
+		cb.Append(d)
+	}
+	// the buffer keeps 0 nil 1 nil 2 nil, make the oldest nil
+	cb.Set(0, nil)
+	if n := cb.Compact(); n != 4 {
+		t.Fatal(n)
+	}
+	if g := cb.Get(); len(g) != 2 || g[0] != 1 || g[1] != 2 {
+		t.Fatal(g)
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := cb.Compact(); n != 0 {
+		t.Fatal(n)
+	}
+	for i := 3; i < 10; i++ {
+		cb.Append(i)
+	}
+	if g := cb.Get(); len(g) != 6 || g[0] != 4 || g[5] != 9 {
+		t.Fatal(g)
+	}
+}
+
+func TestReplaceNewest(t *testing.T) {
+	cb := New(3)
+	if cb.ReplaceNewest(0) || cb.Len() != 1 {
+		t.Fatal()
+	}
+	_, seq := cb.AppendSince(0)
+	if !cb.ReplaceNewest(1) || cb.Len() != 1 {
+		t.Fatal()
+	}
+	if items, _ := cb.AppendSince(seq); len(items) != 1 || items[0] != 1 {
+		t.Fatal(items)
+	}
+	cb.Append(2)
+	cb.Append(3)
+	cb.ReplaceNewest(4)
+	if g := cb.Get(); len(g) != 3 || g[0] != 1 || g[2] != 4 {
+		t.Fatal(g)
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaceNewestHooks(t *testing.T) {
+	cb := New(3)
+	var events []string
+	cb.SetObserver(func(e string, _, _ int) { events = append(events, e) })
+	cb.AddAppendMiddleware(func(d interface{}) (interface{}, bool) {
+		if d == -1 {
+			return nil, false
+		}
+		return d.(int) * 10, true
+	})
+	ch, stop := cb.Subscribe()
+	defer stop()
+	cb.Append(1)
+	if !cb.ReplaceNewest(2) {
+		t.Fatal()
+	}
+	if cb.ReplaceNewest(-1) {
+		t.Fatal()
+	}
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{20}) {
+		t.Fatal(got)
+	}
+	if !reflect.DeepEqual(events, []string{EventAppend, EventReplace}) {
+		t.Fatal(events)
+	}
+	if <-ch != 10 || <-ch != 20 {
+		t.Fatal()
+	}
+	cb.RecordOps(true)
+	cb.ReplaceNewest(3)
+	if r := Replay(cb.Ops()); !reflect.DeepEqual(r.Get(), []interface{}{30}) {
+		t.Fatal(r.Get())
+	}
+}
+
+func TestCyclicIterator(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	it := cb.CreateCyclicIterator()
+	cb.Append(4)
+	for i := 0; i < 7; i++ {
+		if !it.Next() || it.Value() != 1+i%3 {
+			t.Fatal(i)
+		}
+	}
+	e := New(2).CreateCyclicIterator()
+	if e.Next() || e.Value() != nil {
+		t.Fatal()
+	}
+}
+
+func TestFreeSlots(t *testing.T) {
+	cb := New(3)
+	if cb.FreeSlots() != 3 {
+		t.Fatal()
+	}
+	cb.Append(0)
+	if cb.FreeSlots() != 2 {
+		t.Fatal()
+	}
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	if cb.FreeSlots() != 0 {
+		t.Fatal()
+	}
+	cb.Pop()
+	cb.Pop()
+	if cb.FreeSlots() != 2 {
+		t.Fatal()
+	}
+}
+
+func TestAppendAt(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		pos := cb.AppendAt(i)
+		if v, ok := cb.At(pos); !ok || v != i {
+			t.Fatal(i, pos, v)
+		}
+		if pos != cb.Len()-1 {
+			t.Fatal(pos)
+		}
+	}
+	if New(0).AppendAt(1) != -1 {
+		t.Fatal()
+	}
+}
+
+func TestReadLocked(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	sum := 0
+	f := func(slots []interface{}, start, count int) {
+		for i := 0; i < count; i++ {
+			v := slots[(start+i)%len(slots)].(int)
+			if v != i+2 {
+				t.Fatal(v)
+			}
+			sum += v
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() { cb.ReadLocked(f) })
+	if allocs != 0 {
+		t.Fatal(allocs)
+	}
+	if sum == 0 {
+		t.Fatal()
+	}
+	New(0).ReadLocked(func(slots []interface{}, start, count int) {
+		if count != 0 {
+			t.Fatal()
+		}
+	})
+}
+
+func windows(it *WindowIterator) [][]interface{} {
+	res := [][]interface{}{}
+	for it.Next() {
+		res = append(res, it.Value())
+	}
+	return res
+}
+
+func TestWindowIterator(t *testing.T) {
+	cb := New(5)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	got := windows(cb.CreateWindowIterator(3, 1))
+	want := [][]interface{}{{2, 3, 4}, {3, 4, 5}, {4, 5, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	got = windows(cb.CreateWindowIterator(2, 2))
+	want = [][]interface{}{{2, 3}, {4, 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	if got := windows(cb.CreateWindowIterator(6, 1)); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if got := windows(cb.CreateWindowIterator(0, 1)); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if got := windows(cb.CreateWindowIterator(5, 0)); len(got) != 1 {
+		t.Fatal(got)
+	}
+	it := cb.CreateWindowIterator(5, 1)
+	it.Value()
+	if it.Value() != nil {
+		t.Fatal()
+	}
+}
+
+func TestGetReuse(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if got := cb.GetReuse(); !reflect.DeepEqual(got, []interface{}{2, 3, 4, 5}) {
+		t.Fatal(got)
+	}
+	allocs := testing.AllocsPerRun(100, func() { cb.GetReuse() })
+	if allocs != 0 {
+		t.Fatal(allocs)
+	}
+	cb.Pop()
+	if got := cb.GetReuse(); !reflect.DeepEqual(got, []interface{}{3, 4, 5}) {
+		t.Fatal(got)
+	}
+	cb.Append(6)
+	if got := cb.GetReuse(); !reflect.DeepEqual(got, []interface{}{3, 4, 5, 6}) {
+		t.Fatal(got)
+	}
+}
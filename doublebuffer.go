@@ -0,0 +1,56 @@
+package cyclicbuffer
+
+import (
+	"sync"
+)
+
+// DoubleBuffer is a pair of cyclic buffers. Append writes to the active
+// buffer, Snapshot swaps the active and the spare buffers and copies
+// the retired buffer without holding the lock of the producers
+// Get() copies a large buffer under the lock and blocks Append for
+// the whole copy, Snapshot blocks Append only for the swap
+// DoubleBuffer allocates two buffers of the same size, the memory
+// cost is twice the memory cost of a CyclicBuffer
+type DoubleBuffer struct {
+	// mutex protects the active buffer pointer and serializes Append
+	mutex  sync.Mutex
+	active *CyclicBuffer
+	spare  *CyclicBuffer
+	// snapshot serializes the calls to Snapshot
+	snapshot sync.Mutex
+}
+
+// NewDoubleBuffered creates a pair of buffers of the given size
+func NewDoubleBuffered(size int) *DoubleBuffer {
+	return &DoubleBuffer{active: New(size), spare: New(size)}
+}
+
+// Append adds an item to the active buffer
+// Returns position of the next entry
+func (db *DoubleBuffer) Append(d interface{}) int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.active.Append(d)
+}
+
+// Len returns the number of items in the active buffer
+func (db *DoubleBuffer) Len() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.active.Len()
+}
+
+// Snapshot returns the items appended since the previous Snapshot,
+// oldest first, at most the size of the buffer. The active buffer
+// is empty after the call, Snapshot is a Drain() which does not
+// block Append while copying the data
+func (db *DoubleBuffer) Snapshot() []interface{} {
+	db.snapshot.Lock()
+	defer db.snapshot.Unlock()
+	db.mutex.Lock()
+	retired := db.active
+	db.active, db.spare = db.spare, retired
+	db.mutex.Unlock()
+	// Append does not write to the retired buffer anymore
+	return retired.Drain()
+}
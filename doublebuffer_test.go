@@ -0,0 +1,78 @@
+package cyclicbuffer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDoubleBuffer(t *testing.T) {
+	db := NewDoubleBuffered(3)
+	for i := 0; i < 5; i++ {
+		db.Append(i)
+	}
+	s := db.Snapshot()
+	if len(s) != 3 || s[0] != 2 || db.Len() != 0 {
+		t.Fatal(s)
+	}
+	db.Append(7)
+	s = db.Snapshot()
+	if len(s) != 1 || s[0] != 7 {
+		t.Fatal(s)
+	}
+	var wg sync.WaitGroup
+	total := 0
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			db.Append(i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		total += len(db.Snapshot())
+	}
+	wg.Wait()
+	total += len(db.Snapshot())
+	if total > 1000 {
+		t.Fatal(total)
+	}
+}
+
+func benchStall(b *testing.B, snapshot func(), appendf func(interface{})) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				snapshot()
+			}
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		appendf(i)
+	}
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+func BenchmarkAppendDuringGet(b *testing.B) {
+	cb := New(1 << 16)
+	for i := 0; i < 1<<16; i++ {
+		cb.Append(i)
+	}
+	benchStall(b, func() { cb.Get() }, func(d interface{}) { cb.Append(d) })
+}
+
+func BenchmarkAppendDuringSnapshot(b *testing.B) {
+	db := NewDoubleBuffered(1 << 16)
+	for i := 0; i < 1<<16; i++ {
+		db.Append(i)
+	}
+	benchStall(b, func() { db.Snapshot() }, func(d interface{}) { db.Append(d) })
+}
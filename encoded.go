@@ -0,0 +1,139 @@
+package cyclicbuffer
+
+import (
+	"sync"
+)
+
+// Codec converts the items of an EncodedBuffer to bytes and back
+// Decode gets a slice of the internal memory of the buffer, Decode
+// shall copy the bytes if the returned item keeps them
+type Codec interface {
+	Encode(d interface{}) []byte
+	Decode(data []byte) interface{}
+}
+
+// EncodedBuffer is a thread safe cyclic buffer which keeps the items
+// encoded. I use this buffer for large logs of structs: the buffer
+// keeps all records in one []byte and the GC does not scan the items
+//
+// The records have variable length. Append adds the record to the end
+// of the memory and keeps the position and the length of the record
+// in a ring of size slots. When the memory is exhausted Append copies
+// the live records to a new memory twice as large as the live records
+type EncodedBuffer struct {
+	codec Codec
+	// arena keeps the records, the old records remain in the arena
+	// until the next compaction
+	arena   []byte
+	offsets []int
+	lengths []int
+	size    int
+	// index is the slot of the next record
+	index int
+	count int
+	// live is the number of bytes in the records of the items
+	live  int
+	mutex *sync.RWMutex
+}
+
+// NewEncoded creates a buffer
+func NewEncoded(size int, codec Codec) *EncodedBuffer {
+	return &EncodedBuffer{
+		codec:   codec,
+		offsets: make([]int, size),
+		lengths: make([]int, size),
+		size:    size,
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+// Append encodes the item and adds it to the cyclic buffer
+// Append copies the encoded bytes, the codec can reuse the slice
+// Returns position of the next entry
+func (eb *EncodedBuffer) Append(d interface{}) int {
+	record := eb.codec.Encode(d)
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+	if eb.size == 0 {
+		// There is no room, the item is lost immediately
+		return 0
+	}
+	if eb.count == eb.size {
+		// The oldest record is overwritten
+		eb.live -= eb.lengths[eb.physical(0)]
+		eb.count--
+	}
+	if len(eb.arena)+len(record) > cap(eb.arena) {
+		eb.compact(len(record))
+	}
+	eb.offsets[eb.index] = len(eb.arena)
+	eb.lengths[eb.index] = len(record)
+	eb.arena = append(eb.arena, record...)
+	eb.index = (eb.index + 1) % eb.size
+	eb.count++
+	eb.live += len(record)
+	return eb.index
+}
+
+// compact copies the live records to a new arena with room for
+// additional bytes. The caller holds the mutex
+func (eb *EncodedBuffer) compact(additional int) {
+	arena := make([]byte, 0, 2*(eb.live+additional))
+	for i := 0; i < eb.count; i++ {
+		index := eb.physical(i)
+		offset := eb.offsets[index]
+		eb.offsets[index] = len(arena)
+		arena = append(arena, eb.arena[offset:offset+eb.lengths[index]]...)
+	}
+	eb.arena = arena
+}
+
+// physical translates a logical position to a slot
+// The caller holds the mutex and checks the range
+func (eb *EncodedBuffer) physical(i int) int {
+	return (eb.index - eb.count + i + eb.size) % eb.size
+}
+
+// Len returns the number of items stored in the buffer
+func (eb *EncodedBuffer) Len() int {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	return eb.count
+}
+
+// Cap returns the capacity of the buffer
+func (eb *EncodedBuffer) Cap() int {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	return eb.size
+}
+
+// ByteLen returns the number of bytes in the records of the stored items
+func (eb *EncodedBuffer) ByteLen() int {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	return eb.live
+}
+
+// ByteCap returns the size of the memory allocated for the records
+// The memory grows when Append needs room, see EncodedBuffer
+func (eb *EncodedBuffer) ByteCap() int {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	return cap(eb.arena)
+}
+
+// Get returns the decoded items, oldest first
+// Get calls Decode while the buffer is locked, Decode shall not call
+// the buffer API
+func (eb *EncodedBuffer) Get() []interface{} {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	res := make([]interface{}, 0, eb.count)
+	for i := 0; i < eb.count; i++ {
+		index := eb.physical(i)
+		offset := eb.offsets[index]
+		res = append(res, eb.codec.Decode(eb.arena[offset:offset+eb.lengths[index]]))
+	}
+	return res
+}
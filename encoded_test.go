@@ -0,0 +1,72 @@
+package cyclicbuffer
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+type event struct {
+	id   uint32
+	name string
+}
+
+type eventCodec struct{}
+
+func (eventCodec) Encode(d interface{}) []byte {
+	e := d.(event)
+	b := make([]byte, 4, 4+len(e.name))
+	binary.BigEndian.PutUint32(b, e.id)
+	return append(b, e.name...)
+}
+
+func (eventCodec) Decode(data []byte) interface{} {
+	return event{id: binary.BigEndian.Uint32(data), name: string(data[4:])}
+}
+
+func TestEncoded(t *testing.T) {
+	eb := NewEncoded(3, eventCodec{})
+	for i := 0; i < 100; i++ {
+		eb.Append(event{id: uint32(i), name: strings.Repeat("x", i%7)})
+	}
+	g := eb.Get()
+	if len(g) != 3 || eb.Len() != 3 || eb.Cap() != 3 {
+		t.Fatal(g)
+	}
+	for i, d := range g {
+		id := 97 + i
+		if d != (event{id: uint32(id), name: strings.Repeat("x", id%7)}) {
+			t.Fatal(g)
+		}
+	}
+	if cap(eb.arena) > 1000 {
+		t.Fatal(cap(eb.arena))
+	}
+	z := NewEncoded(0, eventCodec{})
+	z.Append(event{})
+	if z.Len() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestEncodedByteLen(t *testing.T) {
+	eb := NewEncoded(2, eventCodec{})
+	if eb.ByteLen() != 0 || eb.ByteCap() != 0 {
+		t.Fatal()
+	}
+	eb.Append(event{name: "abcdef"})
+	eb.Append(event{name: "ab"})
+	if eb.ByteLen() != 10+6 || eb.ByteCap() < eb.ByteLen() {
+		t.Fatal(eb.ByteLen(), eb.ByteCap())
+	}
+	eb.Append(event{})
+	if eb.ByteLen() != 6+4 {
+		t.Fatal(eb.ByteLen())
+	}
+	for i := 0; i < 100; i++ {
+		eb.Append(event{name: "x"})
+	}
+	if eb.ByteLen() != 10 || eb.ByteCap() > 100 {
+		t.Fatal(eb.ByteLen(), eb.ByteCap())
+	}
+}
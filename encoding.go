@@ -0,0 +1,176 @@
+package cyclicbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonBuffer is the JSON representation of the buffer
+type jsonBuffer struct {
+	Size int           `json:"size"`
+	Data []interface{} `json:"data"`
+}
+
+// MarshalJSON implements json.Marshaler
+// The items are stored from the oldest to the newest
+func (cb *CyclicBuffer) MarshalJSON() ([]byte, error) {
+	cb.mutex.RLock()
+	b := jsonBuffer{Size: cb.size, Data: cb.get()}
+	cb.mutex.RUnlock()
+	return json.Marshal(b)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+// The JSON decoder decides the types of the items, for example
+// all numbers are restored as float64
+// Returns an error if the size exceeds MaxUnmarshalSize
+func (cb *CyclicBuffer) UnmarshalJSON(data []byte) error {
+	var b jsonBuffer
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	return cb.restore(b.Size, b.Data)
+}
+
+// gobBuffer is the gob representation of the buffer
+type gobBuffer struct {
+	Size int
+	Data []interface{}
+}
+
+// GobEncode implements gob.GobEncoder
+// The items are interfaces, the caller shall gob.Register() the
+// concrete types of the stored items
+func (cb *CyclicBuffer) GobEncode() ([]byte, error) {
+	cb.mutex.RLock()
+	b := gobBuffer{Size: cb.size, Data: cb.get()}
+	cb.mutex.RUnlock()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder
+// Returns an error if the size exceeds MaxUnmarshalSize
+func (cb *CyclicBuffer) GobDecode(data []byte) error {
+	var b gobBuffer
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err != nil {
+		return err
+	}
+	return cb.restore(b.Size, b.Data)
+}
+
+// ElemCodec encodes and decodes the items for MarshalBinary() and
+// UnmarshalBinary(), see SetBinaryCodec()
+type ElemCodec interface {
+	EncodeElem(d interface{}) ([]byte, error)
+	DecodeElem(data []byte) (interface{}, error)
+}
+
+// SetBinaryCodec sets the codec used by MarshalBinary() and UnmarshalBinary()
+func (cb *CyclicBuffer) SetBinaryCodec(codec ElemCodec) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.binaryCodec = codec
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+// The format is uvarint size, the slot of the oldest item, the
+// number of items, and the items, oldest first. Every item is
+// uvarint length followed by the bytes returned by the codec
+func (cb *CyclicBuffer) MarshalBinary() ([]byte, error) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.binaryCodec == nil {
+		return nil, ErrNoCodec
+	}
+	count := cb.len()
+	res := make([]byte, 0, 3*binary.MaxVarintLen64)
+	res = binary.AppendUvarint(res, uint64(cb.size))
+	res = binary.AppendUvarint(res, uint64(cb.start))
+	res = binary.AppendUvarint(res, uint64(count))
+	for i := 0; i < count; i++ {
+		b, err := cb.binaryCodec.EncodeElem(cb.data[cb.physical(i)])
+		if err != nil {
+			return nil, err
+		}
+		res = binary.AppendUvarint(res, uint64(len(b)))
+		res = append(res, b...)
+	}
+	return res, nil
+}
+
+// MaxUnmarshalSize is the largest size of a buffer UnmarshalJSON(),
+// GobDecode() and UnmarshalBinary() accept, the limit protects from
+// the corrupted data
+const MaxUnmarshalSize = 1 << 24
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// The buffer restores the positions of the items exactly
+// Call SetBinaryCodec() before UnmarshalBinary()
+// Returns an error if the size exceeds MaxUnmarshalSize
+func (cb *CyclicBuffer) UnmarshalBinary(data []byte) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.binaryCodec == nil {
+		return ErrNoCodec
+	}
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("%w: corrupted header", ErrInvalidSize)
+		}
+		header[i], data = v, data[n:]
+	}
+	size, start, count := header[0], header[1], header[2]
+	if size > MaxUnmarshalSize {
+		return fmt.Errorf("%w: size %d exceeds %d", ErrInvalidSize, size, MaxUnmarshalSize)
+	}
+	if count > size || (start >= size && size > 0) || (start > 0 && size == 0) {
+		return fmt.Errorf("%w: %d items from slot %d do not fit size %d", ErrInvalidSize, count, start, size)
+	}
+	// Every item takes at least one byte, the length of the item
+	if count > uint64(len(data)) {
+		return fmt.Errorf("%w: %d items in %d bytes", ErrInvalidSize, count, len(data))
+	}
+	items := make([]interface{}, 0, count)
+	for i := uint64(0); i < count; i++ {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return fmt.Errorf("%w: corrupted item %d", ErrInvalidSize, i)
+		}
+		data = data[n:]
+		d, err := cb.binaryCodec.DecodeElem(data[:length])
+		if err != nil {
+			return err
+		}
+		items = append(items, d)
+		data = data[length:]
+	}
+	cb.loadAt(int(size), int(start), items)
+	return nil
+}
+
+// restore reinitializes the buffer with the items, oldest first
+// The buffer can be a zero value, for example, a target of json.Unmarshal()
+func (cb *CyclicBuffer) restore(size int, items []interface{}) error {
+	if size < 0 || len(items) > size {
+		return fmt.Errorf("%w: %d items do not fit size %d", ErrInvalidSize, len(items), size)
+	}
+	if size > MaxUnmarshalSize {
+		return fmt.Errorf("%w: size %d exceeds %d", ErrInvalidSize, size, MaxUnmarshalSize)
+	}
+	if cb.mutex == nil {
+		cb.initLocks()
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.load(size, items)
+	return nil
+}
@@ -0,0 +1,152 @@
+package cyclicbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	for _, n := range []int{0, 2, 3, 5} {
+		cb := New(3)
+		for i := 0; i < n; i++ {
+			cb.Append(float64(i))
+		}
+		b, err := json.Marshal(cb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r CyclicBuffer
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cb.Get(), r.Get()) || r.Cap() != 3 || r.Len() != cb.Len() {
+			t.Fatal(string(b), r.Get())
+		}
+		r.Append(9.0)
+		cb.Append(9.0)
+		if !reflect.DeepEqual(cb.Get(), r.Get()) {
+			t.Fatal(n, cb.Get(), r.Get())
+		}
+	}
+}
+
+func TestUnmarshalJSONCrafted(t *testing.T) {
+	for _, data := range []string{
+		`{"size":4000000000000,"data":[]}`,
+		`{"size":-1,"data":[]}`,
+		`{"size":1,"data":[1,2]}`,
+	} {
+		var r CyclicBuffer
+		if err := json.Unmarshal([]byte(data), &r); !errors.Is(err, ErrInvalidSize) {
+			t.Fatal(data, err)
+		}
+	}
+}
+
+type gobItem struct {
+	A int
+	B string
+}
+
+func TestGob(t *testing.T) {
+	gob.Register(gobItem{})
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(gobItem{i, "x"})
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cb); err != nil {
+		t.Fatal(err)
+	}
+	var r CyclicBuffer
+	if err := gob.NewDecoder(&buf).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cb.Get(), r.Get()) || r.Cap() != 3 {
+		t.Fatal(r.Get())
+	}
+	p := New(3)
+	p.Append(1)
+	p.Append(nil)
+	if _, err := p.GobEncode(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGobDecodeCrafted(t *testing.T) {
+	for _, b := range []gobBuffer{
+		{Size: 4000000000000},
+		{Size: -1},
+		{Size: 1, Data: []interface{}{1, 2}},
+	} {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+			t.Fatal(err)
+		}
+		var r CyclicBuffer
+		if err := r.GobDecode(buf.Bytes()); !errors.Is(err, ErrInvalidSize) {
+			t.Fatal(b.Size, err)
+		}
+	}
+}
+
+type intCodec struct{}
+
+func (intCodec) EncodeElem(d interface{}) ([]byte, error) {
+	return []byte{byte(d.(int))}, nil
+}
+
+func (intCodec) DecodeElem(b []byte) (interface{}, error) {
+	return int(b[0]), nil
+}
+
+func TestBinary(t *testing.T) {
+	for _, n := range []int{0, 2, 4, 6} {
+		cb := New(4)
+		cb.SetBinaryCodec(intCodec{})
+		for i := 0; i < n; i++ {
+			cb.Append(i)
+		}
+		b, err := cb.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := New(0)
+		r.SetBinaryCodec(intCodec{})
+		if err := r.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r.Get(), cb.Get()) || r.start != cb.start || r.index != cb.index || r.full != cb.full {
+			t.Fatal(n)
+		}
+		b2, _ := r.MarshalBinary()
+		if !bytes.Equal(b, b2) {
+			t.Fatal()
+		}
+		if err := r.UnmarshalBinary(b[:len(b)-1]); err == nil && n > 0 {
+			t.Fatal()
+		}
+	}
+	if _, err := New(1).MarshalBinary(); err == nil {
+		t.Fatal()
+	}
+}
+
+func TestUnmarshalBinaryCrafted(t *testing.T) {
+	cb := New(1)
+	cb.SetBinaryCodec(intCodec{})
+	for _, h := range [][3]uint64{{1 << 62, 0, 1 << 61}, {1 << 63, 0, 0}, {10, 0, 10}, {MaxUnmarshalSize + 1, 0, 0}} {
+		var b []byte
+		for _, v := range h {
+			b = binary.AppendUvarint(b, v)
+		}
+		if err := cb.UnmarshalBinary(b); !errors.Is(err, ErrInvalidSize) {
+			t.Fatal(h, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package cyclicbuffer
+
+import (
+	"errors"
+)
+
+// The errors of the buffers wrap one of these errors, use errors.Is()
+// to check the reason of a failure. The errors of the context, the
+// codecs, the readers and the writers are returned as is
+var (
+	// ErrOutOfRange is returned for a logical position or a range
+	// outside of the stored items, see Set(), GetRange()
+	ErrOutOfRange = errors.New("cyclicbuffer: out of range")
+	// ErrInvalidSize is returned for a negative size, for data which
+	// does not fit the capacity and for corrupted data, see NewChecked(),
+	// Resize(), UnmarshalBinary()
+	ErrInvalidSize = errors.New("cyclicbuffer: invalid size")
+	// ErrFull is returned when there is no room for an item, see AppendWait()
+	ErrFull = errors.New("cyclicbuffer: buffer is full")
+	// ErrEmpty is returned by the calls which need an item in an
+	// empty buffer, see Set()
+	ErrEmpty = errors.New("cyclicbuffer: buffer is empty")
+	// ErrInvalidToken is returned for a token of another buffer, see Commit()
+	ErrInvalidToken = errors.New("cyclicbuffer: invalid token")
+	// ErrInvalidType is returned for an item of an unexpected type, see GetInts()
+	ErrInvalidType = errors.New("cyclicbuffer: invalid type")
+	// ErrNoCodec is returned if the codec is not set, see SetBinaryCodec()
+	ErrNoCodec = errors.New("cyclicbuffer: codec is not set")
+	// ErrSharedLock is returned for two buffers which shall not share
+	// the lock, see SetOverflow()
+	ErrSharedLock = errors.New("cyclicbuffer: shared lock")
+	// ErrInvalidState is returned for a broken invariant, see Validate()
+	ErrInvalidState = errors.New("cyclicbuffer: invalid state")
+)
@@ -0,0 +1,75 @@
+package cyclicbuffer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	if _, err := NewChecked(-1); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	cb := New(2)
+	if err := cb.Resize(-1); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	if err := cb.Grow(-1); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	if err := cb.Set(0, 1); !errors.Is(err, ErrEmpty) {
+		t.Fatal(err)
+	}
+	cb.Append(1)
+	if err := cb.Set(1, 1); !errors.Is(err, ErrOutOfRange) || errors.Is(err, ErrEmpty) {
+		t.Fatal(err)
+	}
+	if _, err := cb.GetRange(0, 2); !errors.Is(err, ErrOutOfRange) {
+		t.Fatal(err)
+	}
+	if err := cb.CreateIterator().Seek(5); !errors.Is(err, ErrOutOfRange) {
+		t.Fatal(err)
+	}
+	if err := New(0).AppendWait(context.Background(), 1); !errors.Is(err, ErrFull) {
+		t.Fatal(err)
+	}
+	_, token := New(2).ReadBatch(1)
+	if err := cb.Commit(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatal(err)
+	}
+	if err := NewByteBuffer(4).WriteRecord([]byte("x")); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	if _, err := cb.ReadFramesFrom(bytes.NewReader([]byte{0, 0}), 16, nil); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	cb.full = true
+	if err := cb.Validate(); !errors.Is(err, ErrInvalidState) {
+		t.Fatal(err)
+	}
+}
+
+func TestSentinelErrorsMore(t *testing.T) {
+	cb := New(2)
+	if err := cb.SetOverflow(cb); !errors.Is(err, ErrSharedLock) {
+		t.Fatal(err)
+	}
+	cb.Append("x")
+	if _, err := cb.GetInts(); !errors.Is(err, ErrInvalidType) {
+		t.Fatal(err)
+	}
+	if _, err := cb.MarshalBinary(); !errors.Is(err, ErrNoCodec) {
+		t.Fatal(err)
+	}
+	if err := cb.UnmarshalBinary(nil); !errors.Is(err, ErrNoCodec) {
+		t.Fatal(err)
+	}
+	cb.SetBinaryCodec(intCodec{})
+	if err := cb.UnmarshalBinary([]byte{0x80}); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	if err := New(0).Set(0, 1); err == nil || err.Error() != "cyclicbuffer: buffer is empty: index 0" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,166 @@
+package cyclicbuffer
+
+import (
+	"sync"
+)
+
+// Buffer is a thread safe cyclic buffer of elements of type T
+// Buffer mirrors the CyclicBuffer API, but avoids boxing the
+// elements and the type assertions on the read path
+type Buffer[T any] struct {
+	data  []T
+	full  bool
+	size  int
+	index int
+	mutex *sync.Mutex
+}
+
+// NewBuffer creates a buffer of elements of type T
+func NewBuffer[T any](size int) *Buffer[T] {
+	return &Buffer[T]{
+		mutex: &sync.Mutex{},
+		data:  make([]T, size),
+		index: 0,
+		full:  false,
+		size:  size,
+	}
+}
+
+// Empty returns true is the buffer is empty
+func (b *Buffer[T]) Empty() bool {
+	return !b.NotEmpty()
+}
+
+// NotEmpty returns true is the buffer is not empty
+func (b *Buffer[T]) NotEmpty() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return (b.full || (b.index > 0))
+}
+
+// Len returns the number of items stored in the buffer
+func (b *Buffer[T]) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.len()
+}
+
+// len is Len() for callers holding the mutex
+func (b *Buffer[T]) len() int {
+	if b.full {
+		return b.size
+	}
+	return b.index
+}
+
+// Cap returns the capacity of the buffer
+func (b *Buffer[T]) Cap() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.size
+}
+
+// Append adds an item to the cyclic buffer
+// Returns position of the next entry
+func (b *Buffer[T]) Append(d T) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.appendLocked(d)
+}
+
+// appendLocked does the actual work, the caller holds the mutex
+func (b *Buffer[T]) appendLocked(d T) int {
+	if b.size == 0 {
+		// There is no room, the item is lost immediately
+		return 0
+	}
+	var index = b.index
+	b.data[index] = d
+	index++
+	if index >= b.size {
+		index = 0
+		b.full = true
+	}
+	b.index = index
+	return index
+}
+
+// Clear removes all items from the buffer
+func (b *Buffer[T]) Clear() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var zero T
+	for i := range b.data {
+		b.data[i] = zero
+	}
+	b.index = 0
+	b.full = false
+}
+
+// BufferIterator object supporting loops over a Buffer
+// The iterator keeps a copy of the items, Append() does not affect
+// an existing iterator
+type BufferIterator[T any] struct {
+	index int
+	// data is the snapshot of the buffer, oldest item first
+	data []T
+}
+
+// CreateIterator returns a new iterator
+func (b *Buffer[T]) CreateIterator() *BufferIterator[T] {
+	return &BufferIterator[T]{data: b.Get()}
+}
+
+// Value returns item from the iterator
+// Value returns zero value of T if there are no more items
+func (it *BufferIterator[T]) Value() T {
+	if it.index >= len(it.data) {
+		var zero T
+		return zero
+	}
+	value := it.data[it.index]
+	it.index++
+	return value
+}
+
+// Next returns true if there anything else
+func (it *BufferIterator[T]) Next() bool {
+	return it.index < len(it.data)
+}
+
+// Get returns a copy of the stored data
+// This is not a deep copy
+func (b *Buffer[T]) Get() []T {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	res := make([]T, 0, b.len())
+	b.walk(func(d T) {
+		res = append(res, d)
+	})
+	return res
+}
+
+// ForEach calls f for each item in the buffer, from the oldest
+// to the newest
+// ForEach holds the mutex while calling f, f shall not call the buffer API
+func (b *Buffer[T]) ForEach(f func(value T)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.walk(f)
+}
+
+// walk is ForEach() for callers holding the mutex
+func (b *Buffer[T]) walk(f func(value T)) {
+	var index int
+	count := b.len()
+	if b.full {
+		index = b.index
+	}
+	for i := 0; i < count; i++ {
+		f(b.data[index])
+		index++
+		if index >= b.size {
+			index = 0
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenericInt(t *testing.T) {
+	b := NewBuffer[int](3)
+	for i := 0; i < 5; i++ {
+		b.Append(i)
+	}
+	if !reflect.DeepEqual(b.Get(), []int{2, 3, 4}) {
+		t.Fatal(b.Get())
+	}
+	it := b.CreateIterator()
+	var got []int
+	for it.Next() {
+		got = append(got, it.Value())
+	}
+	if !reflect.DeepEqual(got, []int{2, 3, 4}) || b.Len() != 3 {
+		t.Fatal(got)
+	}
+	s := NewBuffer[string](2)
+	s.Append("a")
+	if s.Empty() || s.Get()[0] != "a" {
+		t.Fatal()
+	}
+}
+
+func TestGenericIteratorSnapshot(t *testing.T) {
+	b := NewBuffer[int](3)
+	b.Append(0)
+	b.Append(1)
+	it := b.CreateIterator()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 2; i < 1000; i++ {
+			b.Append(i)
+		}
+	}()
+	var got []int
+	for it.Next() {
+		got = append(got, it.Value())
+	}
+	<-done
+	// Append does not affect the iterator
+	if !reflect.DeepEqual(got, []int{0, 1}) || it.Value() != 0 {
+		t.Fatal(got)
+	}
+}
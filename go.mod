@@ -0,0 +1,3 @@
+module github.com/larytet-go/cyclicbuffer
+
+go 1.20
@@ -0,0 +1,276 @@
+package cyclicbuffer
+
+import (
+	"fmt"
+)
+
+// SetEvictionHandler sets a function which is called by Append
+// when the buffer is full and the oldest item is overwritten
+// The handler is called while the buffer is locked, before the item
+// is overwritten. The handler shall not call the buffer API
+// Set nil to remove the handler
+func (cb *CyclicBuffer) SetEvictionHandler(f func(evicted interface{})) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.evictionHandler = f
+}
+
+// SetOverflow sets a buffer which gets the overwritten items: Append
+// adds the oldest item to the overflow buffer before overwriting it
+// I use the overflow buffer to spill the log to the disk
+// Append adds the items to the overflow buffer after releasing the
+// lock of the buffer, the items of concurrent Append calls can reach
+// the overflow buffer in a different order. The overflow buffer shall
+// not use the lock of the buffer and shall not overflow, directly or
+// not, back to the buffer
+// Set nil to remove the overflow buffer
+func (cb *CyclicBuffer) SetOverflow(overflow *CyclicBuffer) error {
+	if overflow == cb || (overflow != nil && sharedLock(overflow.mutex, cb.mutex)) {
+		return fmt.Errorf("%w: the overflow buffer shares the lock of the buffer", ErrSharedLock)
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.overflow = overflow
+	return nil
+}
+
+// SetBatchEvictionHandler sets a function which is called by
+// AppendAll once with all overwritten items, oldest first. If the
+// function is set AppendAll does not call the eviction handler, see
+// SetEvictionHandler(). Append still calls the eviction handler
+// The handler is called while the buffer is locked, after all items
+// are added. The handler shall not call the buffer API
+// Set nil to remove the handler
+func (cb *CyclicBuffer) SetBatchEvictionHandler(f func(evicted []interface{})) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.batchEvictionHandler = f
+}
+
+// flushEvicted calls the batch eviction handler with the collected
+// items, the caller holds the mutex
+func (cb *CyclicBuffer) flushEvicted() {
+	batch := cb.evictedBatch
+	cb.evictedBatch = nil
+	if len(batch) > 0 {
+		cb.call(func() { cb.batchEvictionHandler(batch) })
+	}
+}
+
+// SetDedup sets a function which compares two items. If the function
+// is set Append drops an item equal to the newest item in the buffer
+// and counts the repeats, see Repeats()
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to disable the dedup
+func (cb *CyclicBuffer) SetDedup(eq func(a, b interface{}) bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.dedup = eq
+}
+
+// SetGlobalDedup sets a function which compares two items. If the
+// function is set Append drops an item equal to any stored item, the
+// stored item keeps the position. The buffer never keeps two equal
+// items. Append calls the function for every stored item, the cost
+// of Append is O(Len()). The dropped items are counted in Repeats()
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to disable the dedup
+func (cb *CyclicBuffer) SetGlobalDedup(eq func(a, b interface{}) bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.globalDedup = eq
+}
+
+// Repeats returns the number of items dropped by the dedup
+func (cb *CyclicBuffer) Repeats() uint64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.repeats
+}
+
+// SetUniqueKey sets a function which returns the key of an item
+// If the function is set the buffer keeps only the newest item for
+// every key: Append removes the older item with the same key from the
+// buffer before adding the new item. Append calls the function for
+// every stored item, the cost of Append is O(Len())
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to disable
+func (cb *CyclicBuffer) SetUniqueKey(key func(interface{}) string) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.uniqueKey = key
+}
+
+// Events reported to the observer, see SetObserver()
+const (
+	EventAppend = "append"
+	EventEvict  = "evict"
+	// EventReplace is reported by ReplaceNewest()
+	EventReplace = "replace"
+)
+
+// SetObserver sets a function which is called after every Append
+// with the event name, the number of items and the capacity of the
+// buffer. If Append overwrites the oldest item the observer gets
+// EventEvict before EventAppend
+// The observer is called while the buffer is locked, the observer
+// shall not call the buffer API. Set nil to remove the observer
+func (cb *CyclicBuffer) SetObserver(o func(event string, len, cap int)) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.observer = o
+}
+
+// observe reports an event to the observer, the caller holds the mutex
+func (cb *CyclicBuffer) observe(event string) {
+	if cb.observer != nil {
+		cb.call(func() { cb.observer(event, cb.len(), cb.size) })
+	}
+}
+
+// evicted is called for an item which is about to be overwritten
+// The caller holds the mutex
+func (cb *CyclicBuffer) evicted(d interface{}) {
+	cb.totalEvicted++
+	if cb.overflow != nil {
+		// I do not lock the overflow buffer here, see unlock()
+		cb.spilled = append(cb.spilled, d)
+	}
+	if cb.evictedBatch != nil {
+		cb.evictedBatch = append(cb.evictedBatch, d)
+		return
+	}
+	if cb.evictionHandler != nil {
+		cb.call(func() { cb.evictionHandler(d) })
+	}
+}
+
+// unlock releases the mutex and adds the overwritten items to the
+// overflow buffer, see SetOverflow(). The functions locking two buffers
+// lock them in the order of lockOrder(), locking the overflow buffer
+// while holding the mutex can deadlock with them
+func (cb *CyclicBuffer) unlock() {
+	overflow, spilled := cb.overflow, cb.spilled
+	cb.spilled = nil
+	cb.mutex.Unlock()
+	if overflow == nil {
+		return
+	}
+	for _, d := range spilled {
+		overflow.Append(d)
+	}
+}
+
+// flushSpilled adds the overwritten items to the overflow buffer
+// The functions locking two buffers defer flushSpilled() before
+// locking, the caller does not hold the mutex
+func (cb *CyclicBuffer) flushSpilled() {
+	cb.mutex.Lock()
+	cb.unlock()
+}
+
+// SetWatermark sets a function which is called by Append when the
+// number of items reaches the threshold. The function gets a copy of
+// the stored items, oldest first. The function is called once when
+// Len() crosses the threshold, not for every Append above the
+// threshold; after Pop() or Clear() bring Len() below the threshold
+// the next crossing calls the function again
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to remove the function
+func (cb *CyclicBuffer) SetWatermark(threshold int, onReach func(snapshot []interface{})) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.watermark = threshold
+	cb.onWatermark = onReach
+}
+
+// checkWatermark calls the watermark function if Append brought Len()
+// from below the threshold to the threshold. The caller holds the mutex
+func (cb *CyclicBuffer) checkWatermark(before int) {
+	if cb.onWatermark == nil {
+		return
+	}
+	if before < cb.watermark && cb.len() >= cb.watermark {
+		cb.call(func() { cb.onWatermark(cb.get()) })
+	}
+}
+
+// SetAutoGrow enables the auto grow mode. In this mode Append does not
+// overwrite the oldest item, instead Append doubles the capacity of a
+// full buffer, like append() does for a slice. The buffer never drops
+// items and the memory grows without a limit, use this mode only if
+// the number of items is bounded by other means
+// AppendIfNotFull() and AppendWait() still see a full buffer as full
+// SetAutoGrow(true) sets the Grow policy, SetAutoGrow(false) sets the
+// Overwrite policy, see Policy
+func (cb *CyclicBuffer) SetAutoGrow(enable bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.policy = Overwrite
+	if enable {
+		cb.policy = Grow
+	}
+}
+
+// AddAppendMiddleware adds a function which processes every item
+// before Append stores it. The function returns the item to store or
+// false to drop the item. Append calls the functions in the order they
+// were added, every function gets the item returned by the previous one
+// I use the middlewares for redacting and filtering the log
+// The functions are called while the buffer is locked, the functions
+// shall not call the buffer API
+func (cb *CyclicBuffer) AddAppendMiddleware(f func(interface{}) (interface{}, bool)) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.middlewares = append(cb.middlewares, f)
+}
+
+// SetPanicHandler sets a function which gets the panics of the
+// functions called by the buffer: the eviction handlers, the observer,
+// the watermark function, the middlewares, the dedup functions, the
+// key functions, sizeOf and the rolling sum function. If the function
+// is set the buffer recovers the panic, calls the function and
+// continues: a panicking middleware does not change the item, a
+// panicking dedup function does not drop the item, an item with a
+// panicking key function has no key, sizeOf and the rolling sum
+// function return 0. If the function is not set the panic reaches
+// the caller
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to remove the function
+func (cb *CyclicBuffer) SetPanicHandler(f func(recovered interface{})) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.panicHandler = f
+}
+
+// call calls the user function f and recovers the panic if the panic
+// handler is set. The caller holds the mutex
+func (cb *CyclicBuffer) call(f func()) {
+	if cb.panicHandler == nil {
+		f()
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			cb.panicHandler(r)
+		}
+	}()
+	f()
+}
+
+// compare returns eq(a, b), false if eq panics, see SetPanicHandler()
+// The caller holds the mutex
+func (cb *CyclicBuffer) compare(eq func(a, b interface{}) bool, a, b interface{}) (res bool) {
+	cb.call(func() { res = eq(a, b) })
+	return res
+}
+
+// keyOf returns key(d), false if key is nil or panics, see
+// SetPanicHandler(). The caller holds the mutex
+func (cb *CyclicBuffer) keyOf(key func(interface{}) string, d interface{}) (k string, ok bool) {
+	if key == nil {
+		return "", false
+	}
+	cb.call(func() { k, ok = key(d), true })
+	return k, ok
+}
@@ -0,0 +1,370 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEviction(t *testing.T) {
+	cb := New(3)
+	var ev []interface{}
+	cb.SetEvictionHandler(func(d interface{}) { ev = append(ev, d) })
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	if !reflect.DeepEqual(ev, []interface{}{0, 1}) {
+		t.Fatal(ev)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	cb := New(3)
+	cb.SetDedup(func(a, b interface{}) bool { return a == b })
+	cb.Append(1)
+	i := cb.Append(1)
+	if i != 1 || cb.Len() != 1 || cb.Repeats() != 1 {
+		t.Fatal()
+	}
+	cb.Append(2)
+	cb.Append(1)
+	if !reflect.DeepEqual(cb.Get(), []interface{}{1, 2, 1}) {
+		t.Fatal(cb.Get())
+	}
+}
+
+func TestObserver(t *testing.T) {
+	cb := New(2)
+	var ev []string
+	var lens []int
+	cb.SetObserver(func(e string, l, c int) { ev = append(ev, e); lens = append(lens, l); _ = c })
+	for i := 0; i < 3; i++ {
+		cb.Append(i)
+	}
+	if !reflect.DeepEqual(ev, []string{"append", "append", "evict", "append"}) || !reflect.DeepEqual(lens, []int{1, 2, 2, 2}) {
+		t.Fatal(ev, lens)
+	}
+}
+
+func TestUniqueKey(t *testing.T) {
+	cb := New(3)
+	cb.SetUniqueKey(func(d interface{}) string { return d.(string)[:1] })
+	for _, s := range []string{"a1", "b1", "a2", "c1", "b2", "d1"} {
+		cb.Append(s)
+	}
+	// a1 b1 -> b1 a2 -> b1 a2 c1 -> a2 c1 b2 -> c1 b2 d1
+	if !reflect.DeepEqual(cb.Get(), []interface{}{"c1", "b2", "d1"}) {
+		t.Fatal(cb.Get())
+	}
+	cb.Append("b3")
+	if !reflect.DeepEqual(cb.Get(), []interface{}{"c1", "d1", "b3"}) {
+		t.Fatal(cb.Get())
+	}
+	cb.Append("b4")
+	if !reflect.DeepEqual(cb.Get(), []interface{}{"c1", "d1", "b4"}) {
+		t.Fatal(cb.Get())
+	}
+}
+
+func TestWatermark(t *testing.T) {
+	cb := New(4)
+	var fired [][]interface{}
+	cb.SetWatermark(3, func(s []interface{}) { fired = append(fired, s) })
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if len(fired) != 1 || len(fired[0]) != 3 || fired[0][2] != 2 {
+		t.Fatal(fired)
+	}
+	cb.Pop()
+	cb.Pop()
+	cb.Append(6)
+	if len(fired) != 2 || len(fired[1]) != 3 {
+		t.Fatal(fired)
+	}
+	cb.SetWatermark(3, nil)
+	cb.Clear()
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	if len(fired) != 2 {
+		t.Fatal(fired)
+	}
+}
+
+func TestAutoGrow(t *testing.T) {
+	cb := New(2)
+	cb.SetAutoGrow(true)
+	for i := 0; i < 9; i++ {
+		cb.Append(i)
+	}
+	if cb.Cap() != 16 || cb.Len() != 9 {
+		t.Fatal(cb.Cap(), cb.Len())
+	}
+	for i, v := range cb.Get() {
+		if v != i {
+			t.Fatal(cb.Get())
+		}
+	}
+	if _, e := cb.Stats(); e != 0 {
+		t.Fatal(e)
+	}
+	z := New(0)
+	z.SetAutoGrow(true)
+	z.Append(1)
+	z.Append(2)
+	if z.Cap() != 2 || z.Len() != 2 {
+		t.Fatal(z.Cap(), z.Len())
+	}
+	z.SetAutoGrow(false)
+	z.Append(3)
+	if g := z.Get(); z.Cap() != 2 || g[0] != 2 || g[1] != 3 {
+		t.Fatal(g)
+	}
+}
+
+func TestAppendMiddleware(t *testing.T) {
+	cb := New(4)
+	cb.AddAppendMiddleware(func(d interface{}) (interface{}, bool) {
+		return d, d.(int)%2 == 0
+	})
+	cb.AddAppendMiddleware(func(d interface{}) (interface{}, bool) {
+		return d.(int) * 10, true
+	})
+	cb.AddAppendMiddleware(func(d interface{}) (interface{}, bool) {
+		return d.(int) + 1, true
+	})
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	if g := cb.Get(); len(g) != 3 || g[0] != 1 || g[1] != 21 || g[2] != 41 {
+		t.Fatal(g)
+	}
+	if a, _ := cb.Stats(); a != 3 {
+		t.Fatal(a)
+	}
+}
+
+func TestBatchEvictionHandler(t *testing.T) {
+	cb := New(3)
+	var single []interface{}
+	var batches [][]interface{}
+	cb.SetEvictionHandler(func(d interface{}) { single = append(single, d) })
+	cb.SetBatchEvictionHandler(func(b []interface{}) { batches = append(batches, b) })
+	cb.AppendAll([]interface{}{0, 1, 2})
+	if len(batches) != 0 {
+		t.Fatal(batches)
+	}
+	cb.AppendAll([]interface{}{3, 4, 5, 6, 7})
+	if len(batches) != 1 || len(single) != 0 {
+		t.Fatal(batches, single)
+	}
+	for i, d := range batches[0] {
+		if d != i {
+			t.Fatal(batches)
+		}
+	}
+	if len(batches[0]) != 5 {
+		t.Fatal(batches)
+	}
+	cb.Append(8)
+	if len(single) != 1 || single[0] != 5 {
+		t.Fatal(single)
+	}
+	if _, e := cb.Stats(); e != 6 {
+		t.Fatal(e)
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	cb := New(2)
+	cb.SetEvictionHandler(func(interface{}) { panic("evict") })
+	cb.AddAppendMiddleware(func(d interface{}) (interface{}, bool) {
+		if d == 3 {
+			panic("middleware")
+		}
+		return d, true
+	})
+	var panics []interface{}
+	cb.SetPanicHandler(func(r interface{}) { panics = append(panics, r) })
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	if len(panics) != 3 || panics[0] != "evict" || panics[1] != "middleware" {
+		t.Fatal(panics)
+	}
+	if g := cb.Get(); g[0] != 2 || g[1] != 3 {
+		t.Fatal(g)
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cb.SetPanicHandler(nil)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal()
+			}
+		}()
+		cb.Append(4)
+	}()
+	if cb.Len() != 2 {
+		t.Fatal()
+	}
+}
+
+func TestPanicHandlerAllCallbacks(t *testing.T) {
+	cb := New(2)
+	panics := 0
+	cb.SetPanicHandler(func(interface{}) { panics++ })
+	boom := func(d interface{}) bool { return d == 3 }
+	cb.SetDedup(func(a, b interface{}) bool {
+		if boom(b) {
+			panic("dedup")
+		}
+		return false
+	})
+	cb.SetUniqueKey(func(d interface{}) string {
+		if boom(d) {
+			panic("unique")
+		}
+		return "k"
+	})
+	cb.SetKeyFunc(func(d interface{}) string {
+		if boom(d) {
+			panic("key")
+		}
+		return "k"
+	})
+	cb.SetRollingSum(func(d interface{}) float64 {
+		if boom(d) {
+			panic("extract")
+		}
+		return float64(d.(int))
+	})
+	cb.Append(1)
+	cb.Append(3)
+	if cb.Len() != 2 || panics == 0 {
+		t.Fatal(cb.Get(), panics)
+	}
+	if cb.RollingSum() != 1 {
+		t.Fatal(cb.RollingSum())
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	cb, of := New(3), New(10)
+	if err := cb.SetOverflow(cb); err == nil {
+		t.Fatal()
+	}
+	var mu sync.Mutex
+	if err := NewWithMutex(2, &mu).SetOverflow(NewWithMutex(2, &mu)); err == nil {
+		t.Fatal()
+	}
+	if err := cb.SetOverflow(of); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	if got := of.Get(); !reflect.DeepEqual(got, []interface{}{0, 1, 2, 3}) {
+		t.Fatal(got)
+	}
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{4, 5, 6}) {
+		t.Fatal(got)
+	}
+	cb.SetOverflow(nil)
+	cb.Append(7)
+	if of.Len() != 4 {
+		t.Fatal(of.Len())
+	}
+}
+
+func TestOverflowNoDeadlock(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	// The lock of the overflow buffer precedes the lock of the buffer
+	// in one of the pairs, see lockOrder()
+	a, b := New(4), New(4)
+	p, q := New(4), New(4)
+	if err := a.SetOverflow(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetOverflow(p); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*CyclicBuffer{{a, b}, {q, p}} {
+		src, of := pair[0], pair[1]
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				src.Append(i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				SnapshotAll(src, of)
+				of.Equal(src, nil)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	// MoveTo and Merge overflow after releasing both locks
+	c := New(2)
+	c.SetOverflow(b)
+	b.Clear()
+	a.MoveTo(c)
+	if got := b.Get(); !reflect.DeepEqual(got, []interface{}{9996, 9997}) {
+		t.Fatal(got)
+	}
+	c.Merge(New(0))
+	c.Merge(NewFromSlice([]interface{}{1}))
+	if got := b.Get(); !reflect.DeepEqual(got, []interface{}{9996, 9997, 9998}) {
+		t.Fatal(got)
+	}
+}
+
+func TestGlobalDedup(t *testing.T) {
+	cb := New(4)
+	cb.SetGlobalDedup(func(a, b interface{}) bool { return a == b })
+	for _, v := range []int{1, 2, 1, 3, 2, 4, 5, 1} {
+		cb.Append(v)
+	}
+	// 1 is evicted by 5, the last 1 is distinct from the window
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{3, 4, 5, 1}) {
+		t.Fatal(got)
+	}
+	if cb.Repeats() != 2 {
+		t.Fatal(cb.Repeats())
+	}
+}
+
+func TestGlobalDedupPanic(t *testing.T) {
+	cb := New(2)
+	panics := 0
+	cb.SetPanicHandler(func(interface{}) { panics++ })
+	cb.SetGlobalDedup(func(a, b interface{}) bool {
+		if b == 3 {
+			panic("global")
+		}
+		return false
+	})
+	cb.Append(1)
+	cb.Append(3)
+	if cb.Len() != 2 || panics != 1 {
+		t.Fatal(cb.Get(), panics)
+	}
+}
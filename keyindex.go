@@ -0,0 +1,69 @@
+package cyclicbuffer
+
+// keySlot is the position of the newest item with the key
+type keySlot struct {
+	slot int
+	seq  uint64
+}
+
+// SetKeyFunc sets a function which returns the key of an item
+// If the function is set the buffer keeps a map from the key to the
+// newest item with this key, see GetByKey(). Unlike SetUniqueKey()
+// the buffer keeps all items, the cost of Append does not change
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to drop the map
+func (cb *CyclicBuffer) SetKeyFunc(key func(interface{}) string) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.keyFunc = key
+	cb.keyIndexRebuild()
+}
+
+// GetByKey returns the newest item with the key
+// Returns false if there is no such item in the buffer or the key
+// function is not set, see SetKeyFunc()
+func (cb *CyclicBuffer) GetByKey(key string) (interface{}, bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	ks, ok := cb.keyIndex[key]
+	if !ok {
+		return nil, false
+	}
+	return cb.data[ks.slot], true
+}
+
+// keyIndexAdd adds the item in the slot to the map
+// The caller holds the mutex
+func (cb *CyclicBuffer) keyIndexAdd(slot int) {
+	if key, ok := cb.keyOf(cb.keyFunc, cb.data[slot]); ok {
+		cb.keyIndex[key] = keySlot{slot: slot, seq: cb.seqs[slot]}
+	}
+}
+
+// keyIndexRemove removes the item in the slot from the map, if the item
+// is the newest item with this key. The key of the item shall not
+// change since keyIndexAdd(), AppendReuse() removes the item before
+// the update modifies it. The caller holds the mutex
+func (cb *CyclicBuffer) keyIndexRemove(slot int) {
+	key, ok := cb.keyOf(cb.keyFunc, cb.data[slot])
+	if !ok {
+		return
+	}
+	if ks, ok := cb.keyIndex[key]; ok && ks.slot == slot && ks.seq == cb.seqs[slot] {
+		delete(cb.keyIndex, key)
+	}
+}
+
+// keyIndexRebuild builds the map from scratch, I call it after the
+// items move to other slots. The caller holds the mutex
+func (cb *CyclicBuffer) keyIndexRebuild() {
+	if cb.keyFunc == nil {
+		cb.keyIndex = nil
+		return
+	}
+	cb.keyIndex = make(map[string]keySlot, cb.len())
+	count := cb.len()
+	for i := 0; i < count; i++ {
+		cb.keyIndexAdd(cb.physical(i))
+	}
+}
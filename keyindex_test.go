@@ -0,0 +1,111 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+type kv struct {
+	k string
+	v int
+}
+
+func TestGetByKey(t *testing.T) {
+	cb := New(3)
+	cb.Append(kv{"a", 0})
+	cb.SetKeyFunc(func(d interface{}) string { return d.(kv).k })
+	if d, ok := cb.GetByKey("a"); !ok || d.(kv).v != 0 {
+		t.Fatal(d)
+	}
+	cb.Append(kv{"b", 1})
+	cb.Append(kv{"a", 2})
+	if d, ok := cb.GetByKey("a"); !ok || d.(kv).v != 2 {
+		t.Fatal(d)
+	}
+	cb.Append(kv{"c", 3})
+	// a:0 is evicted, a:2 remains
+	if d, ok := cb.GetByKey("a"); !ok || d.(kv).v != 2 {
+		t.Fatal(d)
+	}
+	cb.Append(kv{"d", 4})
+	if _, ok := cb.GetByKey("b"); ok {
+		t.Fatal()
+	}
+	if _, ok := cb.GetByKey("x"); ok {
+		t.Fatal()
+	}
+	cb.Pop()
+	if _, ok := cb.GetByKey("a"); ok {
+		t.Fatal()
+	}
+	cb.Set(0, kv{"e", 5})
+	if _, ok := cb.GetByKey("c"); ok {
+		t.Fatal()
+	}
+	if d, ok := cb.GetByKey("e"); !ok || d.(kv).v != 5 {
+		t.Fatal(d)
+	}
+	cb.Resize(5)
+	cb.Append(kv{"f", 6})
+	if d, ok := cb.GetByKey("d"); !ok || d.(kv).v != 4 {
+		t.Fatal(d)
+	}
+	if len(cb.keyIndex) != 3 {
+		t.Fatal(cb.keyIndex)
+	}
+	cb.Clear()
+	if _, ok := cb.GetByKey("d"); ok {
+		t.Fatal()
+	}
+	cb.SetKeyFunc(nil)
+	cb.Append(kv{"g", 7})
+	if _, ok := cb.GetByKey("g"); ok {
+		t.Fatal()
+	}
+}
+
+func TestGetByKeyReplaceNewest(t *testing.T) {
+	cb := New(3)
+	cb.SetKeyFunc(func(d interface{}) string { return d.(kv).k })
+	cb.Append(kv{"a", 1})
+	cb.Append(kv{"a", 2})
+	cb.ReplaceNewest(kv{"b", 3})
+	// a:1 remains in the buffer
+	if d, ok := cb.GetByKey("a"); !ok || d.(kv).v != 1 {
+		t.Fatal(d, ok)
+	}
+	if d, ok := cb.GetByKey("b"); !ok || d.(kv).v != 3 {
+		t.Fatal(d, ok)
+	}
+	cb.ReplaceNewest(kv{"b", 4})
+	if d, ok := cb.GetByKey("b"); !ok || d.(kv).v != 4 {
+		t.Fatal(d, ok)
+	}
+	cb.ReplaceNewest(kv{"c", 5})
+	if _, ok := cb.GetByKey("b"); ok {
+		t.Fatal()
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetByKeyAppendReuse(t *testing.T) {
+	cb := New(2)
+	cb.SetKeyFunc(func(d interface{}) string { return d.(*kv).k })
+	cb.Append(&kv{"a", 1})
+	cb.Append(&kv{"b", 2})
+	cb.AppendReuse(func(old interface{}) interface{} {
+		it := old.(*kv)
+		it.k, it.v = "c", 10
+		return it
+	})
+	if _, ok := cb.GetByKey("a"); ok {
+		t.Fatal("a")
+	}
+	if d, ok := cb.GetByKey("c"); !ok || d.(*kv).v != 10 {
+		t.Fatal(d)
+	}
+	if d, ok := cb.GetByKey("b"); !ok || d.(*kv).v != 2 {
+		t.Fatal(d)
+	}
+}
@@ -0,0 +1,78 @@
+package cyclicbuffer
+
+import (
+	"sync"
+)
+
+// rwLocker is the lock protecting the buffer
+// The read paths call RLock(), the rest call Lock()
+type rwLocker interface {
+	sync.Locker
+	RLock()
+	RUnlock()
+}
+
+// NewWithMutex creates a buffer which uses the lock mu
+// I use this constructor when the buffer is a field of a larger struct
+// which already has a lock: all buffers and the struct can share one
+// lock. If mu has RLock() and RUnlock() methods, for example
+// *sync.RWMutex, the readers do not block each other
+// The buffer calls mu.Lock() in every method, the caller shall not hold
+// mu while calling the buffer API
+func NewWithMutex(size int, mu sync.Locker) *CyclicBuffer {
+	cb := New(size)
+	cb.setLock(mu)
+	return cb
+}
+
+// NewUnlocked creates a buffer which does not lock at all
+// The buffer is not thread safe, the caller shall serialize all calls
+// to the buffer API, for example with its own mutex. PopWait() and
+// AppendWait() require a lock and shall not be used with this buffer
+func NewUnlocked(size int) *CyclicBuffer {
+	cb := New(size)
+	cb.setLock(noLock{})
+	return cb
+}
+
+// setLock replaces the lock and the condition variables
+func (cb *CyclicBuffer) setLock(mu sync.Locker) {
+	if l, ok := mu.(rwLocker); ok {
+		cb.mutex = l
+	} else {
+		cb.mutex = exclusiveLock{mu}
+	}
+	cb.notEmpty = sync.NewCond(cb.mutex)
+	cb.notFull = sync.NewCond(cb.mutex)
+}
+
+// exclusiveLock is a sync.Locker without a read lock, the readers
+// take the exclusive lock
+type exclusiveLock struct {
+	sync.Locker
+}
+
+func (l exclusiveLock) RLock() {
+	l.Lock()
+}
+
+func (l exclusiveLock) RUnlock() {
+	l.Unlock()
+}
+
+// noLock is the lock of NewUnlocked()
+type noLock struct{}
+
+func (noLock) Lock()    {}
+func (noLock) Unlock()  {}
+func (noLock) RLock()   {}
+func (noLock) RUnlock() {}
+
+// sharedLock returns true if both locks are the same lock, see
+// NewWithMutex(). The buffers of NewUnlocked() do not share a lock
+func sharedLock(a, b rwLocker) bool {
+	if _, ok := a.(noLock); ok {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,61 @@
+package cyclicbuffer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWithMutex(t *testing.T) {
+	var mu sync.Mutex
+	a := NewWithMutex(3, &mu)
+	b := NewWithMutex(3, &mu)
+	a.Append(1)
+	b.Append(2)
+	a.Merge(b)
+	if g := a.Get(); len(g) != 2 || g[1] != 2 {
+		t.Fatal(g)
+	}
+	if a.Equal(b, nil) {
+		t.Fatal()
+	}
+	var rw sync.RWMutex
+	c := NewWithMutex(2, &rw)
+	c.Append(1)
+	if c.mutex != &rw {
+		t.Fatal()
+	}
+	if v, _ := c.Pop(); v != 1 {
+		t.Fatal(v)
+	}
+}
+
+func TestUnlocked(t *testing.T) {
+	cb := NewUnlocked(3)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				mu.Lock()
+				cb.Append(i)
+				cb.Len()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if cb.Len() != 3 {
+		t.Fatal(cb.Len())
+	}
+	cb.Merge(NewUnlocked(2))
+	of := NewUnlocked(2)
+	if err := cb.SetOverflow(of); err != nil {
+		t.Fatal(err)
+	}
+	cb.Append(3)
+	if of.Len() != 1 {
+		t.Fatal(of.Len())
+	}
+}
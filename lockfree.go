@@ -0,0 +1,75 @@
+package cyclicbuffer
+
+import (
+	"sync/atomic"
+)
+
+// LockFreeBuffer is a lockless cyclic buffer for a single producer
+// and a single reader
+// Only one goroutine is allowed to call Append() and only one goroutine
+// is allowed to call Snapshot(). I use this buffer for high frequency
+// event logs, where the mutex in the CyclicBuffer is a bottleneck
+type LockFreeBuffer struct {
+	slots []atomic.Pointer[lockFreeSlot]
+	size  uint64
+	// seq is the number of items appended so far
+	seq atomic.Uint64
+}
+
+// lockFreeSlot is an item and its sequence number
+type lockFreeSlot struct {
+	seq   uint64
+	value interface{}
+}
+
+// NewLockFree creates a buffer
+func NewLockFree(size int) *LockFreeBuffer {
+	return &LockFreeBuffer{
+		slots: make([]atomic.Pointer[lockFreeSlot], size),
+		size:  uint64(size),
+	}
+}
+
+// Append adds an item to the cyclic buffer
+// Only one producer is allowed
+func (b *LockFreeBuffer) Append(d interface{}) {
+	if b.size == 0 {
+		return
+	}
+	seq := b.seq.Load()
+	b.slots[seq%b.size].Store(&lockFreeSlot{seq: seq, value: d})
+	b.seq.Store(seq + 1)
+}
+
+// Len returns the number of items stored in the buffer
+func (b *LockFreeBuffer) Len() int {
+	seq := b.seq.Load()
+	if seq > b.size {
+		return int(b.size)
+	}
+	return int(seq)
+}
+
+// Snapshot returns a copy of the stored data, oldest item first
+// Items overwritten by a concurrent Append are not included, the
+// returned items are the window which existed at some point of time
+// Only one reader is allowed
+func (b *LockFreeBuffer) Snapshot() []interface{} {
+	end := b.seq.Load()
+	var begin uint64
+	if end > b.size {
+		begin = end - b.size
+	}
+	res := make([]interface{}, 0, end-begin)
+	for seq := begin; seq < end; seq++ {
+		slot := b.slots[seq%b.size].Load()
+		if slot == nil || slot.seq != seq {
+			// The producer has overwritten the slot, all items
+			// I collected so far are older than the overwritten one
+			res = res[:0]
+			continue
+		}
+		res = append(res, slot.value)
+	}
+	return res
+}
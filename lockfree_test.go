@@ -0,0 +1,47 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestLockFree(t *testing.T) {
+	b := NewLockFree(16)
+	for i := 0; i < 20; i++ {
+		b.Append(i)
+	}
+	s := b.Snapshot()
+	if len(s) != 16 || s[0] != 4 || b.Len() != 16 {
+		t.Fatal(s)
+	}
+	done := make(chan struct{})
+	go func() {
+		for i := 20; i < 200000; i++ {
+			b.Append(i)
+		}
+		close(done)
+	}()
+	for k := 0; k < 2000; k++ {
+		s := b.Snapshot()
+		for j := 1; j < len(s); j++ {
+			if s[j].(int) != s[j-1].(int)+1 {
+				t.Fatal(s)
+			}
+		}
+	}
+	<-done
+	NewLockFree(0).Append(1)
+}
+
+func BenchmarkLockFree(b *testing.B) {
+	lf := NewLockFree(1024)
+	for i := 0; i < b.N; i++ {
+		lf.Append(i)
+	}
+}
+
+func BenchmarkMutex(b *testing.B) {
+	cb := New(1024)
+	for i := 0; i < b.N; i++ {
+		cb.Append(i)
+	}
+}
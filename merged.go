@@ -0,0 +1,73 @@
+package cyclicbuffer
+
+import (
+	"container/heap"
+)
+
+// MergedIterator returns the items of several iterators in the order
+// of Append, see MergeIterators()
+type MergedIterator struct {
+	its iteratorHeap
+}
+
+// iteratorHeap keeps the iterator with the oldest next item at the root
+type iteratorHeap []*Iterator
+
+func (h iteratorHeap) Len() int           { return len(h) }
+func (h iteratorHeap) Less(i, j int) bool { return h[i].pendingSeq() < h[j].pendingSeq() }
+func (h iteratorHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *iteratorHeap) Push(it interface{}) {
+	*h = append(*h, it.(*Iterator))
+}
+func (h *iteratorHeap) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// MergeIterators returns an iterator over the items of all iterators
+// ordered by the time of Append, the oldest item first. The buffers
+// shall share the sequence numbers, see SetGlobalSequence()
+// Use the iterators returned by CreateIterator() and CreateTailIterator()
+// The merged iterator moves the iterators, do not call them directly
+func MergeIterators(its ...*Iterator) *MergedIterator {
+	var mi MergedIterator
+	for _, it := range its {
+		if it != nil && it.Next() {
+			mi.its = append(mi.its, it)
+		}
+	}
+	heap.Init(&mi.its)
+	return &mi
+}
+
+// Next returns true if there anything else
+func (mi *MergedIterator) Next() bool {
+	return len(mi.its) > 0
+}
+
+// Value returns the oldest of the remaining items
+// Value returns nil if there are no more items
+func (mi *MergedIterator) Value() interface{} {
+	if len(mi.its) == 0 {
+		return nil
+	}
+	it := mi.its[0]
+	value := it.Value()
+	if it.Next() {
+		heap.Fix(&mi.its, 0)
+	} else {
+		heap.Pop(&mi.its)
+	}
+	return value
+}
+
+// pendingSeq returns the sequence number of the item which the next
+// Value() returns. The iterators without the sequence numbers return 0
+func (it *Iterator) pendingSeq() uint64 {
+	if it.index < 0 || it.index >= len(it.seqs) {
+		return 0
+	}
+	return it.seqs[it.index]
+}
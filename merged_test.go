@@ -0,0 +1,69 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestMergeIterators(t *testing.T) {
+	a, b := New(4), New(3)
+	a.SetGlobalSequence(true)
+	b.SetGlobalSequence(true)
+	for i := 0; i < 10; i++ {
+		if i%3 == 0 {
+			b.Append(i)
+		} else {
+			a.Append(i)
+		}
+	}
+	// a keeps 4 5 7 8, b keeps 3 6 9
+	mi := MergeIterators(a.CreateIterator(), nil, b.CreateIterator(), New(2).CreateIterator())
+	var got []interface{}
+	for mi.Next() {
+		got = append(got, mi.Value())
+	}
+	want := []interface{}{3, 4, 5, 6, 7, 8, 9}
+	if len(got) != len(want) {
+		t.Fatal(got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatal(got)
+		}
+	}
+	if mi.Value() != nil || MergeIterators().Next() {
+		t.Fatal()
+	}
+}
+
+func TestGlobalSequence(t *testing.T) {
+	a, b := New(4), New(4)
+	a.Append(0)
+	b.Append(0)
+	// Every buffer counts the sequence numbers
+	if _, seq := a.AppendSince(0); seq != 1 {
+		t.Fatal(seq)
+	}
+	if _, seq := b.AppendSince(0); seq != 1 {
+		t.Fatal(seq)
+	}
+	for i := 0; i < 1000; i++ {
+		a.Append(i)
+	}
+	_, last := a.AppendSince(0)
+	a.SetGlobalSequence(true)
+	b.SetGlobalSequence(true)
+	a.Append(1)
+	b.Append(2)
+	a.SetGlobalSequence(false)
+	a.Append(3)
+	r, seq := a.AppendSince(last)
+	if len(r) != 2 || r[0] != 1 || r[1] != 3 || seq <= last+1 {
+		t.Fatal(r, seq, last)
+	}
+	if _, seq := b.AppendSince(0); seq <= last {
+		t.Fatal(seq, last)
+	}
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,195 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// Equal returns true if both buffers contain the same items in the
+// same order. The capacity and the internal positions can differ
+// If eq is nil the items are compared with ==
+func (cb *CyclicBuffer) Equal(other *CyclicBuffer, eq func(a, b interface{}) bool) bool {
+	if other == nil {
+		return false
+	}
+	if eq == nil {
+		eq = equal
+	}
+	first, second := lockOrder(cb, other)
+	first.mutex.RLock()
+	defer first.mutex.RUnlock()
+	if second.mutex != first.mutex {
+		second.mutex.RLock()
+		defer second.mutex.RUnlock()
+	}
+	count := cb.len()
+	if count != other.len() {
+		return false
+	}
+	for i := 0; i < count; i++ {
+		if !eq(cb.data[cb.physical(i)], other.data[other.physical(i)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge appends the items of the other buffer, oldest first, to the
+// buffer. The capacity does not change, Merge can overwrite the oldest
+// items in the buffer. Merge(nil) does nothing
+func (cb *CyclicBuffer) Merge(other *CyclicBuffer) {
+	if other == nil {
+		return
+	}
+	if other.mutex == cb.mutex {
+		// Same buffer or both buffers share the lock, see NewWithMutex()
+		cb.mutex.Lock()
+		defer cb.unlock()
+		cb.appendItems(other.get())
+		return
+	}
+	defer cb.flushSpilled()
+	first, _ := lockOrder(cb, other)
+	if first == cb {
+		cb.mutex.Lock()
+		defer cb.mutex.Unlock()
+		other.mutex.RLock()
+		defer other.mutex.RUnlock()
+	} else {
+		other.mutex.RLock()
+		defer other.mutex.RUnlock()
+		cb.mutex.Lock()
+		defer cb.mutex.Unlock()
+	}
+	cb.appendItems(other.get())
+}
+
+// MoveTo appends the items of the buffer, oldest first, to dst and
+// removes them from the buffer in one lock of both buffers. dst can
+// overwrite its oldest items. The items dst drops, for example, the
+// Reject policy or SetDedup() of dst, remain in the buffer
+// Returns the number of moved items
+func (cb *CyclicBuffer) MoveTo(dst *CyclicBuffer) int {
+	if dst == nil || dst == cb {
+		return 0
+	}
+	defer dst.flushSpilled()
+	first, second := lockOrder(cb, dst)
+	first.mutex.Lock()
+	defer first.mutex.Unlock()
+	if second.mutex != first.mutex {
+		second.mutex.Lock()
+		defer second.mutex.Unlock()
+	}
+	count := cb.len()
+	kept := 0
+	for i := 0; i < count; i++ {
+		from := cb.physical(i)
+		if dst.push(cb.data[from]).stored {
+			continue
+		}
+		to := cb.physical(kept)
+		cb.data[to], cb.seqs[to] = cb.data[from], cb.seqs[from]
+		kept++
+	}
+	if kept == 0 {
+		cb.reset()
+		return count
+	}
+	for i := kept; i < count; i++ {
+		cb.data[cb.physical(i)] = nil
+	}
+	cb.setLayout(cb.size, cb.start, kept)
+	cb.rebuild()
+	if cb.recording {
+		cb.record(Op{Kind: OpLoad, Size: cb.size, Index: cb.start, Value: cb.get()})
+	}
+	return count - kept
+}
+
+// SnapshotAll returns copies of the items of all buffers, oldest
+// first. SnapshotAll locks all buffers before copying, the copies are
+// the state of the buffers at the same point of time
+func SnapshotAll(buffers ...*CyclicBuffer) [][]interface{} {
+	ordered := make([]*CyclicBuffer, 0, len(buffers))
+	for _, b := range buffers {
+		if b != nil {
+			ordered = append(ordered, b)
+		}
+	}
+	// The same order as lockOrder()
+	sort.Slice(ordered, func(i, j int) bool {
+		return lockedBefore(ordered[i], ordered[j])
+	})
+	var locked []rwLocker
+	for _, b := range ordered {
+		if containsLock(locked, b.mutex) {
+			// Same buffer or a shared lock, see NewWithMutex()
+			continue
+		}
+		b.mutex.RLock()
+		defer b.mutex.RUnlock()
+		locked = append(locked, b.mutex)
+	}
+	res := make([][]interface{}, len(buffers))
+	for i, b := range buffers {
+		if b != nil {
+			res[i] = b.get()
+		}
+	}
+	return res
+}
+
+// containsLock returns true if l is in the locks
+func containsLock(locks []rwLocker, l rwLocker) bool {
+	for _, lock := range locks {
+		if lock == l {
+			return true
+		}
+	}
+	return false
+}
+
+// appendItems adds the items, the caller holds the mutex
+func (cb *CyclicBuffer) appendItems(items []interface{}) {
+	for _, d := range items {
+		cb.appendLocked(d)
+	}
+}
+
+// lockOrder returns the buffers in the order I lock them
+// All functions locking two buffers lock the lock with the lower
+// address first, this way two goroutines never deadlock. The buffers
+// share the locks, see NewWithMutex(), the order of the buffers is
+// not the order of the locks
+func lockOrder(a, b *CyclicBuffer) (*CyclicBuffer, *CyclicBuffer) {
+	if lockedBefore(b, a) {
+		return b, a
+	}
+	return a, b
+}
+
+// lockedBefore returns true if the lock of a precedes the lock of b
+// If the addresses of the locks are equal, for example, the locks are
+// not pointers, I compare the addresses of the buffers
+func lockedBefore(a, b *CyclicBuffer) bool {
+	la, lb := lockAddress(a.mutex), lockAddress(b.mutex)
+	if la != lb {
+		return la < lb
+	}
+	return uintptr(unsafe.Pointer(a)) < uintptr(unsafe.Pointer(b))
+}
+
+// lockAddress returns the address of the lock, 0 if the lock is not
+// a pointer, for example, the lock of NewUnlocked()
+func lockAddress(l rwLocker) uintptr {
+	var v interface{} = l
+	if e, ok := l.(exclusiveLock); ok {
+		v = e.Locker
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		return rv.Pointer()
+	}
+	return 0
+}
@@ -0,0 +1,198 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEqual(t *testing.T) {
+	a, b := New(3), New(5)
+	for i := 0; i < 7; i++ {
+		a.Append(i)
+	}
+	for i := 4; i < 7; i++ {
+		b.Append(i)
+	}
+	if !a.Equal(b, nil) || !b.Equal(a, nil) || !a.Equal(a, nil) {
+		t.Fatal()
+	}
+	b.Append(7)
+	if a.Equal(b, nil) || a.Equal(nil, nil) {
+		t.Fatal()
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(4), New(3)
+	for i := 0; i < 3; i++ {
+		a.Append(i)
+		b.Append(10 + i)
+	}
+	a.Merge(b)
+	if g := a.Get(); len(g) != 4 || g[0] != 2 || g[1] != 10 || g[3] != 12 {
+		t.Fatal(g)
+	}
+	if b.Len() != 3 {
+		t.Fatal()
+	}
+	b.Merge(b)
+	if g := b.Get(); len(g) != 3 || g[0] != 10 {
+		t.Fatal(g)
+	}
+	b.Merge(a)
+	a.Merge(b)
+}
+
+func TestMergeNil(t *testing.T) {
+	cb := New(2)
+	cb.Append(1)
+	cb.Merge(nil)
+	if cb.Len() != 1 {
+		t.Fatal()
+	}
+}
+
+func TestLockOrderSharedLocks(t *testing.T) {
+	var a, b sync.RWMutex
+	b0 := NewWithMutex(4, &b)
+	b1 := NewWithMutex(4, &a)
+	b2 := NewWithMutex(4, &b)
+	b3 := NewWithMutex(4, &a)
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				b1.Merge(b2)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				b3.Merge(b0)
+			}
+		}()
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("deadlock")
+	}
+}
+
+func TestSnapshotAll(t *testing.T) {
+	a, b := New(8), New(8)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// Append both under a's and b's locks in order so the
+			// snapshots shall always have equal newest items
+			first, second := lockOrder(a, b)
+			first.mutex.Lock()
+			second.mutex.Lock()
+			a.appendLocked(i)
+			b.appendLocked(i)
+			second.mutex.Unlock()
+			first.mutex.Unlock()
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		s := SnapshotAll(b, a, nil, a)
+		if len(s) != 4 || s[2] != nil || len(s[0]) != len(s[1]) {
+			t.Fatal(s)
+		}
+		if n := len(s[0]); n > 0 && s[0][n-1] != s[1][n-1] {
+			t.Fatal(s)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestMoveTo(t *testing.T) {
+	a, b := New(3), New(4)
+	a.Append(0)
+	a.Append(1)
+	b.Append(-1)
+	if n := a.MoveTo(b); n != 2 || a.Len() != 0 {
+		t.Fatal(n)
+	}
+	if g := b.Get(); len(g) != 3 || g[0] != -1 || g[2] != 1 {
+		t.Fatal(g)
+	}
+	if a.MoveTo(a) != 0 || a.MoveTo(nil) != 0 {
+		t.Fatal()
+	}
+	// The items dst drops remain in the buffer
+	src, dst := NewFromSlice([]interface{}{1, 2, 3}), NewWithPolicy(1, Reject)
+	if n := src.MoveTo(dst); n != 1 {
+		t.Fatal(n)
+	}
+	if !reflect.DeepEqual(src.Get(), []interface{}{2, 3}) || !reflect.DeepEqual(dst.Get(), []interface{}{1}) {
+		t.Fatal(src.Get(), dst.Get())
+	}
+	src, dst = NewFromSlice([]interface{}{1, 2, 2, 3, 3}), New(5)
+	dst.SetDedup(func(a, b interface{}) bool { return a == b })
+	src.RecordOps(true)
+	if n := src.MoveTo(dst); n != 3 {
+		t.Fatal(n)
+	}
+	if !reflect.DeepEqual(src.Get(), []interface{}{2, 3}) || !reflect.DeepEqual(dst.Get(), []interface{}{1, 2, 3}) {
+		t.Fatal(src.Get(), dst.Get())
+	}
+	if src.Len() != 2 || src.Full() || src.Validate() != nil {
+		t.Fatal(src.Len(), src.Validate())
+	}
+	if r := Replay(src.Ops()); !reflect.DeepEqual(r.Get(), src.Get()) {
+		t.Fatal(r.Get())
+	}
+
+	x, y := New(20000), New(20000)
+	const N = 10000
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < N; i++ {
+			x.Append(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			x.MoveTo(y)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			y.MoveTo(x)
+		}
+	}()
+	wg.Wait()
+	x.MoveTo(y)
+	seen := map[interface{}]bool{}
+	for _, d := range y.Get() {
+		if seen[d] {
+			t.Fatal(d)
+		}
+		seen[d] = true
+	}
+	if len(seen) != N {
+		t.Fatal(len(seen))
+	}
+}
@@ -0,0 +1,149 @@
+package cyclicbuffer
+
+import (
+	"math"
+)
+
+// NumericBuffer is a cyclic buffer of float64 with built-in reductions
+// I use this buffer for metrics, the reductions do not box the values
+// and do not need a closure
+type NumericBuffer struct {
+	b *Buffer[float64]
+	// sum of the stored finite values, Append updates the sum in O(1)
+	sum float64
+	// the number of the stored non-finite values, the sum does not
+	// include them: sum - Inf is NaN after the Inf is evicted
+	nan, posInf, negInf int
+}
+
+// NewNumeric creates a buffer of float64
+func NewNumeric(size int) *NumericBuffer {
+	return &NumericBuffer{b: NewBuffer[float64](size)}
+}
+
+// Append adds a value to the buffer, the oldest value is overwritten
+// if the buffer is full. Returns position of the next entry
+func (nb *NumericBuffer) Append(v float64) int {
+	b := nb.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.size == 0 {
+		return 0
+	}
+	if b.full {
+		nb.add(b.data[b.index], -1)
+	}
+	nb.add(v, 1)
+	return b.appendLocked(v)
+}
+
+// add adds the value to the sum if sign is 1, removes the value if
+// sign is -1. The caller holds the mutex
+func (nb *NumericBuffer) add(v float64, sign int) {
+	switch {
+	case math.IsNaN(v):
+		nb.nan += sign
+	case math.IsInf(v, 1):
+		nb.posInf += sign
+	case math.IsInf(v, -1):
+		nb.negInf += sign
+	default:
+		nb.sum += float64(sign) * v
+	}
+}
+
+// total returns the sum of the stored values, the caller holds the mutex
+func (nb *NumericBuffer) total() float64 {
+	switch {
+	case nb.nan > 0 || (nb.posInf > 0 && nb.negInf > 0):
+		return math.NaN()
+	case nb.posInf > 0:
+		return math.Inf(1)
+	case nb.negInf > 0:
+		return math.Inf(-1)
+	}
+	return nb.sum
+}
+
+// Len returns the number of values stored in the buffer
+func (nb *NumericBuffer) Len() int {
+	return nb.b.Len()
+}
+
+// Cap returns the capacity of the buffer
+func (nb *NumericBuffer) Cap() int {
+	return nb.b.Cap()
+}
+
+// Get returns a copy of the stored values, oldest first
+func (nb *NumericBuffer) Get() []float64 {
+	return nb.b.Get()
+}
+
+// Clear removes all values from the buffer
+func (nb *NumericBuffer) Clear() {
+	b := nb.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i := range b.data {
+		b.data[i] = 0
+	}
+	b.index = 0
+	b.full = false
+	nb.sum = 0
+	nb.nan, nb.posInf, nb.negInf = 0, 0, 0
+}
+
+// Sum returns the sum of the stored values in O(1), 0 for an empty buffer
+// The sum accumulates the rounding errors of float64. The sum is +Inf
+// or -Inf if the buffer stores an infinite value, NaN if it stores NaN
+// or both infinities
+func (nb *NumericBuffer) Sum() float64 {
+	nb.b.mutex.Lock()
+	defer nb.b.mutex.Unlock()
+	return nb.total()
+}
+
+// Average returns the mean of the stored values in O(1)
+// Returns false if the buffer is empty
+func (nb *NumericBuffer) Average() (float64, bool) {
+	b := nb.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	count := b.len()
+	if count == 0 {
+		return 0, false
+	}
+	return nb.total() / float64(count), true
+}
+
+// Min returns the smallest stored value in O(n)
+// Returns false if the buffer is empty
+func (nb *NumericBuffer) Min() (float64, bool) {
+	return nb.extreme(func(v, res float64) bool { return v < res })
+}
+
+// Max returns the largest stored value in O(n)
+// Returns false if the buffer is empty
+func (nb *NumericBuffer) Max() (float64, bool) {
+	return nb.extreme(func(v, res float64) bool { return v > res })
+}
+
+// extreme returns the stored value v for which better(v, res) is
+// true against every other value res
+func (nb *NumericBuffer) extreme(better func(v, res float64) bool) (float64, bool) {
+	b := nb.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.len() == 0 {
+		return 0, false
+	}
+	var res float64
+	first := true
+	b.walk(func(v float64) {
+		if first || better(v, res) {
+			res, first = v, false
+		}
+	})
+	return res, true
+}
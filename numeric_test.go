@@ -0,0 +1,90 @@
+package cyclicbuffer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNumeric(t *testing.T) {
+	nb := NewNumeric(3)
+	if _, ok := nb.Average(); ok {
+		t.Fatal()
+	}
+	if _, ok := nb.Min(); ok {
+		t.Fatal()
+	}
+	if nb.Sum() != 0 {
+		t.Fatal()
+	}
+	nb.Append(2)
+	nb.Append(-1)
+	if v, _ := nb.Min(); v != -1 {
+		t.Fatal(v)
+	}
+	if v, _ := nb.Average(); v != 0.5 {
+		t.Fatal(v)
+	}
+	nb.Append(5)
+	nb.Append(4) // drops 2
+	if nb.Sum() != 8 {
+		t.Fatal(nb.Sum())
+	}
+	if v, _ := nb.Max(); v != 5 {
+		t.Fatal(v)
+	}
+	nb.Append(1)
+	nb.Append(0) // -1, 5 gone
+	if v, _ := nb.Min(); v != 0 {
+		t.Fatal(v)
+	}
+	if v, _ := nb.Max(); v != 4 {
+		t.Fatal(v)
+	}
+	if v, _ := nb.Average(); v != 5.0/3 {
+		t.Fatal(v)
+	}
+	nb.Clear()
+	if nb.Sum() != 0 || nb.Len() != 0 {
+		t.Fatal()
+	}
+	z := NewNumeric(0)
+	z.Append(1)
+	if z.Sum() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestNumericNonFinite(t *testing.T) {
+	nb := NewNumeric(2)
+	nb.Append(math.Inf(1))
+	if !math.IsInf(nb.Sum(), 1) {
+		t.Fatal(nb.Sum())
+	}
+	nb.Append(math.Inf(-1))
+	if !math.IsNaN(nb.Sum()) {
+		t.Fatal(nb.Sum())
+	}
+	nb.Append(1) // +Inf gone
+	if !math.IsInf(nb.Sum(), -1) {
+		t.Fatal(nb.Sum())
+	}
+	nb.Append(2)
+	if nb.Sum() != 3 {
+		t.Fatal(nb.Sum())
+	}
+	nb.Append(math.NaN())
+	if v, _ := nb.Average(); !math.IsNaN(v) {
+		t.Fatal(v)
+	}
+	nb.Append(3)
+	nb.Append(4)
+	if v, _ := nb.Average(); v != 3.5 {
+		t.Fatal(v)
+	}
+	nb.Append(math.NaN())
+	nb.Clear()
+	nb.Append(1)
+	if nb.Sum() != 1 {
+		t.Fatal(nb.Sum())
+	}
+}
@@ -0,0 +1,47 @@
+package cyclicbuffer
+
+import (
+	"fmt"
+)
+
+// Policy tells what Append does when the buffer is full
+type Policy int
+
+const (
+	// Overwrite is the default policy: Append overwrites the oldest
+	// item and returns the position of the next entry
+	Overwrite Policy = iota
+	// Reject drops the new item, the buffer is not modified and
+	// Append returns -1. A buffer of size 0 rejects all items
+	Reject
+	// Grow doubles the capacity of the buffer, see SetAutoGrow()
+	Grow
+	// Block waits until Pop() removes an item. The waiting Append
+	// releases the lock, AppendAll() and Merge() can interleave with
+	// other calls. Block requires a lock, do not use Block with
+	// NewUnlocked(). See AppendWait() for a wait with a timeout
+	// A buffer of size 0 rejects all items as Reject does
+	Block
+)
+
+// String returns the name of the policy
+func (p Policy) String() string {
+	switch p {
+	case Overwrite:
+		return "Overwrite"
+	case Reject:
+		return "Reject"
+	case Grow:
+		return "Grow"
+	case Block:
+		return "Block"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// NewWithPolicy creates a buffer with the policy
+func NewWithPolicy(size int, p Policy) *CyclicBuffer {
+	cb := New(size)
+	cb.policy = p
+	return cb
+}
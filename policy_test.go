@@ -0,0 +1,105 @@
+package cyclicbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	o := NewWithPolicy(2, Overwrite)
+	for i := 0; i < 3; i++ {
+		o.Append(i)
+	}
+	if g := o.Get(); g[0] != 1 || g[1] != 2 {
+		t.Fatal(g)
+	}
+
+	r := NewWithPolicy(2, Reject)
+	if r.Append(0) != 1 || r.Append(1) != 0 || r.Append(2) != -1 {
+		t.Fatal()
+	}
+	if g := r.Get(); len(g) != 2 || g[0] != 0 || g[1] != 1 {
+		t.Fatal(g)
+	}
+	z := NewWithPolicy(0, Block)
+	if z.Append(1) != -1 {
+		t.Fatal()
+	}
+	if _, evicted := z.Stats(); evicted != 0 {
+		t.Fatal(evicted)
+	}
+	if NewWithPolicy(0, Reject).Append(1) != -1 {
+		t.Fatal()
+	}
+	if r.Clone().Append(3) != -1 {
+		t.Fatal()
+	}
+
+	g := NewWithPolicy(2, Grow)
+	for i := 0; i < 5; i++ {
+		g.Append(i)
+	}
+	if g.Len() != 5 || g.Cap() != 8 {
+		t.Fatal(g.Len(), g.Cap())
+	}
+
+	b := NewWithPolicy(2, Block)
+	b.Append(0)
+	b.Append(1)
+	done := make(chan int)
+	go func() {
+		done <- b.Append(2)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Append did not block")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if v, _ := b.Pop(); v != 0 {
+		t.Fatal(v)
+	}
+	<-done
+	if g := b.Get(); g[0] != 1 || g[1] != 2 {
+		t.Fatal(g)
+	}
+	if Block.String() != "Block" || Policy(9).String() != "Policy(9)" {
+		t.Fatal()
+	}
+}
+
+func TestPolicyReturns(t *testing.T) {
+	cb := NewWithPolicy(2, Reject)
+	cb.Append(1)
+	if i, full := cb.AppendDetectFull(2); i != 0 || !full {
+		t.Fatal(i, full)
+	}
+	if i, ov := cb.AppendChecked(3); i != -1 || ov {
+		t.Fatal(i, ov)
+	}
+	if i, full := cb.AppendDetectFull(3); i != -1 || full {
+		t.Fatal(i, full)
+	}
+	cb.Pop()
+	if i := cb.AppendAll([]interface{}{4, 5}); i != -1 || cb.Len() != 2 {
+		t.Fatal(i, cb.Get())
+	}
+	g := NewWithPolicy(1, Grow)
+	if i := g.AppendAll([]interface{}{1, 2, 3}); i != 3 || g.Len() != 3 {
+		t.Fatal(i, g.Get())
+	}
+}
+
+func TestAppendDetectFullBlock(t *testing.T) {
+	b := NewWithPolicy(1, Block)
+	b.Append(0)
+	done := make(chan bool)
+	go func() {
+		_, filled := b.AppendDetectFull(1)
+		done <- filled
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Pop()
+	if !<-done {
+		t.Fatal()
+	}
+}
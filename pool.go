@@ -0,0 +1,71 @@
+package cyclicbuffer
+
+import (
+	"sync"
+)
+
+// slots is the memory of a buffer kept in the pool
+type slots struct {
+	data []interface{}
+	seqs []uint64
+}
+
+// pools keeps a sync.Pool of slots for every size, see AcquireBuffer()
+var pools = struct {
+	sync.Mutex
+	bySize map[int]*sync.Pool
+}{bySize: map[int]*sync.Pool{}}
+
+// poolFor returns the pool of the slots of the size
+func poolFor(size int) *sync.Pool {
+	pools.Lock()
+	defer pools.Unlock()
+	p, ok := pools.bySize[size]
+	if !ok {
+		p = &sync.Pool{
+			New: func() interface{} {
+				return &slots{data: make([]interface{}, size), seqs: make([]uint64, size)}
+			},
+		}
+		pools.bySize[size] = p
+	}
+	return p
+}
+
+// AcquireBuffer returns an empty buffer, the same as New(), reusing the
+// memory of a buffer returned by ReleaseBuffer() if possible
+// I use the pool in services which create and drop many short living
+// buffers of the same size
+func AcquireBuffer(size int) *CyclicBuffer {
+	if size < 0 {
+		return New(size)
+	}
+	s := poolFor(size).Get().(*slots)
+	cb := &CyclicBuffer{
+		data: s.data,
+		seqs: s.seqs,
+		size: size,
+	}
+	cb.initLocks()
+	return cb
+}
+
+// ReleaseBuffer removes all items from the buffer and returns the
+// memory to the pool. After the call cb is an empty buffer of size 0,
+// the caller should not use it
+func ReleaseBuffer(cb *CyclicBuffer) {
+	if cb == nil {
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.reset()
+	for i := range cb.seqs {
+		cb.seqs[i] = 0
+	}
+	s := &slots{data: cb.data, seqs: cb.seqs}
+	size := cb.size
+	cb.data, cb.seqs = nil, nil
+	cb.setLayout(0, 0, 0)
+	poolFor(size).Put(s)
+}
@@ -0,0 +1,35 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	cb := AcquireBuffer(3)
+	cb.SetDedup(func(a, b interface{}) bool { return true })
+	cb.Append(1)
+	cb.Append(2)
+	ReleaseBuffer(cb)
+	if cb.Len() != 0 || cb.Cap() != 0 {
+		t.Fatal()
+	}
+	cb.Append(1)
+	for i := 0; i < 10; i++ {
+		c := AcquireBuffer(3)
+		if c.Len() != 0 || c.Cap() != 3 {
+			t.Fatal(c.Len(), c.Cap())
+		}
+		for _, d := range c.AllSlots() {
+			if d != nil {
+				t.Fatal(d)
+			}
+		}
+		c.Append(1)
+		c.Append(2)
+		if c.Len() != 2 {
+			t.Fatal(c.Len())
+		}
+		ReleaseBuffer(c)
+	}
+	ReleaseBuffer(nil)
+}
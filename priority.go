@@ -0,0 +1,93 @@
+package cyclicbuffer
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+// PriorityBuffer is a thread safe buffer which keeps the size items
+// with the highest priority instead of the newest items
+// I use this buffer for the "top N" of the slowest requests
+// Append is O(log(size))
+type PriorityBuffer struct {
+	items priorityHeap
+	size  int
+	mutex *sync.RWMutex
+}
+
+// priorityHeap is a min heap, the item with the lowest priority is
+// at the root
+type priorityHeap struct {
+	data []interface{}
+	less func(a, b interface{}) bool
+}
+
+func (h *priorityHeap) Len() int           { return len(h.data) }
+func (h *priorityHeap) Less(i, j int) bool { return h.less(h.data[i], h.data[j]) }
+func (h *priorityHeap) Swap(i, j int)      { h.data[i], h.data[j] = h.data[j], h.data[i] }
+func (h *priorityHeap) Push(d interface{}) { h.data = append(h.data, d) }
+func (h *priorityHeap) Pop() interface{} {
+	last := len(h.data) - 1
+	d := h.data[last]
+	h.data[last] = nil
+	h.data = h.data[:last]
+	return d
+}
+
+// NewPriority creates a buffer. less(a, b) returns true if the
+// priority of a is lower than the priority of b
+func NewPriority(size int, less func(a, b interface{}) bool) *PriorityBuffer {
+	return &PriorityBuffer{
+		items: priorityHeap{data: make([]interface{}, 0, size), less: less},
+		size:  size,
+		mutex: &sync.RWMutex{},
+	}
+}
+
+// Append adds an item to the buffer. If the buffer is full Append
+// drops the item with the lowest priority, which can be the new item
+// Returns true if the item was added
+// less is called while the buffer is locked, less shall not call the
+// buffer API
+func (pb *PriorityBuffer) Append(d interface{}) bool {
+	pb.mutex.Lock()
+	defer pb.mutex.Unlock()
+	if pb.size == 0 {
+		return false
+	}
+	if len(pb.items.data) < pb.size {
+		heap.Push(&pb.items, d)
+		return true
+	}
+	if !pb.items.less(pb.items.data[0], d) {
+		// The new item has the lowest priority
+		return false
+	}
+	pb.items.data[0] = d
+	heap.Fix(&pb.items, 0)
+	return true
+}
+
+// Len returns the number of items stored in the buffer
+func (pb *PriorityBuffer) Len() int {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+	return len(pb.items.data)
+}
+
+// Cap returns the capacity of the buffer
+func (pb *PriorityBuffer) Cap() int {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+	return pb.size
+}
+
+// Get returns a copy of the stored items, the highest priority first
+func (pb *PriorityBuffer) Get() []interface{} {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+	res := append([]interface{}{}, pb.items.data...)
+	sort.SliceStable(res, func(i, j int) bool { return pb.items.less(res[j], res[i]) })
+	return res
+}
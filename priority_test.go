@@ -0,0 +1,34 @@
+package cyclicbuffer
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestPriority(t *testing.T) {
+	pb := NewPriority(5, func(a, b interface{}) bool { return a.(int) < b.(int) })
+	r := rand.New(rand.NewSource(3))
+	var all []int
+	for i := 0; i < 100; i++ {
+		v := r.Intn(1000)
+		all = append(all, v)
+		pb.Append(v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(all)))
+	g := pb.Get()
+	if len(g) != 5 || pb.Len() != 5 || pb.Cap() != 5 {
+		t.Fatal(g)
+	}
+	for i := range g {
+		if g[i] != all[i] {
+			t.Fatal(g, all[:5])
+		}
+	}
+	if pb.Append(-1) {
+		t.Fatal()
+	}
+	if NewPriority(0, nil).Append(1) {
+		t.Fatal()
+	}
+}
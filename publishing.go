@@ -0,0 +1,67 @@
+package cyclicbuffer
+
+import (
+	"sync/atomic"
+)
+
+// PublishingBuffer is a cyclic buffer with wait free readers
+// Append copies the items to a new immutable slice and publishes the
+// slice every N appends. Snapshot() loads the published slice and never
+// blocks the writers. The price is an allocation of a slice of Cap()
+// items for every publish; with a large N the snapshot lags behind
+// by up to N-1 items
+type PublishingBuffer struct {
+	cb        *CyclicBuffer
+	every     int
+	appends   int
+	published atomic.Value
+}
+
+// NewPublishing creates a buffer which publishes a snapshot every
+// "every" appends. If every is less than 1 every Append publishes
+func NewPublishing(size int, every int) *PublishingBuffer {
+	if every < 1 {
+		every = 1
+	}
+	pb := &PublishingBuffer{cb: New(size), every: every}
+	pb.published.Store([]interface{}{})
+	return pb
+}
+
+// Append adds an item to the cyclic buffer
+// Returns position of the next entry
+func (pb *PublishingBuffer) Append(d interface{}) int {
+	pb.cb.mutex.Lock()
+	defer pb.cb.mutex.Unlock()
+	index := pb.cb.appendLocked(d)
+	pb.appends++
+	if pb.appends >= pb.every {
+		pb.publish()
+	}
+	return index
+}
+
+// Publish publishes the current state of the buffer immediately
+func (pb *PublishingBuffer) Publish() {
+	pb.cb.mutex.Lock()
+	defer pb.cb.mutex.Unlock()
+	pb.publish()
+}
+
+// publish does the actual work, the caller holds the mutex
+func (pb *PublishingBuffer) publish() {
+	pb.appends = 0
+	pb.published.Store(pb.cb.get())
+}
+
+// Snapshot returns the last published items, oldest first
+// Snapshot does not lock. The returned slice is shared by all readers,
+// the caller shall not modify it
+func (pb *PublishingBuffer) Snapshot() []interface{} {
+	return pb.published.Load().([]interface{})
+}
+
+// Get returns a copy of the stored data, see CyclicBuffer.Get()
+func (pb *PublishingBuffer) Get() []interface{} {
+	return pb.cb.Get()
+}
@@ -0,0 +1,46 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestPublishing(t *testing.T) {
+	pb := NewPublishing(3, 2)
+	if len(pb.Snapshot()) != 0 {
+		t.Fatal()
+	}
+	pb.Append(1)
+	if len(pb.Snapshot()) != 0 {
+		t.Fatal()
+	}
+	pb.Append(2)
+	if s := pb.Snapshot(); len(s) != 2 {
+		t.Fatal(s)
+	}
+	pb.Append(3)
+	pb.Publish()
+	if s := pb.Snapshot(); len(s) != 3 || s[2] != 3 {
+		t.Fatal(s)
+	}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10000; i++ {
+			pb.Append(i)
+		}
+		close(done)
+	}()
+	for i := 0; i < 1000; i++ {
+		_ = pb.Snapshot()
+	}
+	<-done
+}
+
+func BenchmarkPublishingSnapshot(b *testing.B) {
+	pb := NewPublishing(1024, 16)
+	for i := 0; i < 2048; i++ {
+		pb.Append(i)
+	}
+	for i := 0; i < b.N; i++ {
+		_ = pb.Snapshot()
+	}
+}
@@ -0,0 +1,195 @@
+package cyclicbuffer
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Contains returns true if the buffer contains the target
+// If eq is nil the items are compared with ==
+func (cb *CyclicBuffer) Contains(target interface{}, eq func(a, b interface{}) bool) bool {
+	return cb.IndexOf(target, eq) >= 0
+}
+
+// IndexOf returns the logical position of the oldest item equal
+// to the target, where 0 is the oldest item, or -1
+// If eq is nil the items are compared with ==
+func (cb *CyclicBuffer) IndexOf(target interface{}, eq func(a, b interface{}) bool) int {
+	if eq == nil {
+		eq = equal
+	}
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	res := -1
+	cb.walk(func(i int, d interface{}) bool {
+		if eq(d, target) {
+			res = i
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// Filter returns the items for which pred returns true, from the
+// oldest to the newest
+// Filter holds the read lock while calling pred, pred shall not call the buffer API
+func (cb *CyclicBuffer) Filter(pred func(interface{}) bool) []interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	res := []interface{}{}
+	cb.walk(func(_ int, d interface{}) bool {
+		if pred(d) {
+			res = append(res, d)
+		}
+		return true
+	})
+	return res
+}
+
+// Count returns the number of items for which pred returns true
+// If pred is nil Count returns the number of items
+// Count holds the read lock while calling pred, pred shall not call the buffer API
+func (cb *CyclicBuffer) Count(pred func(interface{}) bool) int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if pred == nil {
+		return cb.len()
+	}
+	count := 0
+	cb.walk(func(_ int, d interface{}) bool {
+		if pred(d) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// Partition returns the items for which pred returns true and the
+// rest of the items, both oldest first
+// Partition holds the read lock while calling pred, pred shall not call the buffer API
+func (cb *CyclicBuffer) Partition(pred func(interface{}) bool) (match, rest []interface{}) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	match, rest = []interface{}{}, []interface{}{}
+	cb.walk(func(_ int, d interface{}) bool {
+		if pred(d) {
+			match = append(match, d)
+		} else {
+			rest = append(rest, d)
+		}
+		return true
+	})
+	return match, rest
+}
+
+// Sorted returns a copy of the stored data sorted by less
+// The sort is stable, the order of the items in the buffer is not modified
+func (cb *CyclicBuffer) Sorted(less func(a, b interface{}) bool) []interface{} {
+	res := cb.Get()
+	sort.SliceStable(res, func(i, j int) bool {
+		return less(res[i], res[j])
+	})
+	return res
+}
+
+// Reduce calls f for each item from the oldest to the newest and
+// returns the accumulated value. For an empty buffer Reduce returns initial
+// For example, the max of a buffer of ints is
+//
+//	max := cb.Reduce(math.MinInt, func(acc, v interface{}) interface{} {
+//		if v.(int) > acc.(int) {
+//			return v
+//		}
+//		return acc
+//	})
+//
+// Reduce holds the read lock while calling f, f shall not call the buffer API
+func (cb *CyclicBuffer) Reduce(initial interface{}, f func(acc, value interface{}) interface{}) interface{} {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	acc := initial
+	cb.walk(func(_ int, d interface{}) bool {
+		acc = f(acc, d)
+		return true
+	})
+	return acc
+}
+
+// TypeHistogram returns the number of stored items of every type
+// The keys are the type names, for example "int" or "*main.Event"
+// The nil items are counted under "<nil>"
+func (cb *CyclicBuffer) TypeHistogram() map[string]int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	res := map[string]int{}
+	cb.walk(func(_ int, d interface{}) bool {
+		if d == nil {
+			res["<nil>"]++
+		} else {
+			res[reflect.TypeOf(d).String()]++
+		}
+		return true
+	})
+	return res
+}
+
+// Map returns a new buffer of the same capacity containing f(item)
+// for every item, oldest first. The buffer is not modified
+// Map calls f after releasing the lock, f can call the buffer API
+func (cb *CyclicBuffer) Map(f func(interface{}) interface{}) *CyclicBuffer {
+	cb.mutex.RLock()
+	items, size := cb.get(), cb.size
+	cb.mutex.RUnlock()
+	for i, d := range items {
+		items[i] = f(d)
+	}
+	res := New(size)
+	res.load(size, items)
+	return res
+}
+
+// Diff compares two snapshots of a buffer, for example, two results
+// of Get(), and returns the items of new which are not in old and the
+// items of old which are not in new, in the order of the snapshots
+// Every item of old matches one equal item of new, if old contains an
+// item twice and new contains it once one copy is removed
+// If eq is nil the items are compared with ==. Diff is O(len(old)*len(new))
+func Diff(old, new []interface{}, eq func(a, b interface{}) bool) (added, removed []interface{}) {
+	if eq == nil {
+		eq = equal
+	}
+	matched := make([]bool, len(old))
+	added = []interface{}{}
+	for _, n := range new {
+		found := false
+		for i, o := range old {
+			if !matched[i] && eq(o, n) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			added = append(added, n)
+		}
+	}
+	removed = []interface{}{}
+	for i, o := range old {
+		if !matched[i] {
+			removed = append(removed, o)
+		}
+	}
+	return added, removed
+}
+
+// equal compares two interfaces with ==
+// Uncomparable types, for example slices, are never equal
+func equal(a, b interface{}) (res bool) {
+	defer func() {
+		if recover() != nil {
+			res = false
+		}
+	}()
+	return a == b
+}
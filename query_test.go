@@ -0,0 +1,166 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestContains(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	if cb.Contains(1, nil) || !cb.Contains(4, nil) || cb.IndexOf(3, nil) != 1 {
+		t.Fatal()
+	}
+	cb.Append([]int{1})
+	if cb.Contains([]int{1}, nil) {
+		t.Fatal()
+	}
+	if cb.IndexOf(8, func(a, b interface{}) bool { return a == 4 }) != 1 {
+		t.Fatal()
+	}
+}
+
+func TestFilter(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	if len(cb.Filter(func(interface{}) bool { return true })) != 4 {
+		t.Fatal()
+	}
+	if f := cb.Filter(func(interface{}) bool { return false }); f == nil || len(f) != 0 {
+		t.Fatal()
+	}
+	f := cb.Filter(func(v interface{}) bool { return v.(int)%2 == 0 })
+	if len(f) != 2 || f[0] != 4 || f[1] != 6 {
+		t.Fatal(f)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	cb := New(3)
+	if cb.Reduce(5, nil) != 5 {
+		t.Fatal()
+	}
+	for _, v := range []int{4, 9, 1, 7, 2} {
+		cb.Append(v)
+	}
+	sum := cb.Reduce(0, func(a, v interface{}) interface{} { return a.(int) + v.(int) })
+	max := cb.Reduce(-1, func(a, v interface{}) interface{} {
+		if v.(int) > a.(int) {
+			return v
+		}
+		return a
+	})
+	if sum != 10 || max != 7 {
+		t.Fatal(sum, max)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	m, r := cb.Partition(func(v interface{}) bool { return v.(int)%2 == 0 })
+	if len(m) != 2 || m[0] != 4 || m[1] != 6 || len(r) != 2 || r[0] != 3 || r[1] != 5 {
+		t.Fatal(m, r)
+	}
+	m, r = New(2).Partition(func(interface{}) bool { return true })
+	if m == nil || r == nil {
+		t.Fatal()
+	}
+}
+
+func TestCount(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	for _, p := range []func(interface{}) bool{
+		func(v interface{}) bool { return v.(int)%2 == 0 },
+		func(v interface{}) bool { return v.(int) > 4 },
+		func(v interface{}) bool { return false },
+	} {
+		if cb.Count(p) != len(cb.Filter(p)) {
+			t.Fatal()
+		}
+	}
+	if cb.Count(nil) != 4 {
+		t.Fatal()
+	}
+}
+
+func TestSorted(t *testing.T) {
+	cb := New(4)
+	for _, v := range []int{5, 3, 9, 1, 7} {
+		cb.Append(v)
+	}
+	s := cb.Sorted(func(a, b interface{}) bool { return a.(int) < b.(int) })
+	if s[0] != 1 || s[1] != 3 || s[3] != 9 {
+		t.Fatal(s)
+	}
+	if g := cb.Get(); g[0] != 3 || g[3] != 7 {
+		t.Fatal(g)
+	}
+}
+
+func TestTypeHistogram(t *testing.T) {
+	cb := New(5)
+	for _, v := range []interface{}{0, 1, "a", nil, 2, nil} {
+		cb.Append(v)
+	}
+	h := cb.TypeHistogram()
+	if len(h) != 3 || h["int"] != 2 || h["string"] != 1 || h["<nil>"] != 2 {
+		t.Fatal(h)
+	}
+}
+
+func TestMap(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 3; i++ {
+		cb.Append(i)
+	}
+	m := cb.Map(func(d interface{}) interface{} { return d.(int) * 10 })
+	if m.Len() != 3 || m.Cap() != 4 || m.Full() {
+		t.Fatal(m)
+	}
+	if g := m.Get(); g[0] != 0 || g[2] != 20 {
+		t.Fatal(g)
+	}
+	if g := cb.Get(); g[2] != 2 {
+		t.Fatal(g)
+	}
+	m.Append(30)
+	m.Append(40)
+	if g := m.Get(); g[0] != 10 || g[3] != 40 {
+		t.Fatal(g)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	check := func(got, want []interface{}) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatal(got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatal(got, want)
+			}
+		}
+	}
+	a, r := Diff([]interface{}{1, 2, 3}, []interface{}{2, 3, 4, 5}, nil)
+	check(a, []interface{}{4, 5})
+	check(r, []interface{}{1})
+	a, r = Diff([]interface{}{1, 2}, []interface{}{3}, nil)
+	check(a, []interface{}{3})
+	check(r, []interface{}{1, 2})
+	a, r = Diff([]interface{}{1, 1, 2}, []interface{}{1, 2, 2}, nil)
+	check(a, []interface{}{2})
+	check(r, []interface{}{1})
+	a, r = Diff(nil, nil, nil)
+	check(a, nil)
+	check(r, nil)
+}
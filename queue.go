@@ -0,0 +1,287 @@
+package cyclicbuffer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Pop removes the oldest item from the buffer and returns it
+// Pop allows to use the buffer as a bounded FIFO
+// Returns false if the buffer is empty
+func (cb *CyclicBuffer) Pop() (interface{}, bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.popLocked()
+}
+
+// popLocked does the actual work, the caller holds the mutex
+// popLocked sets the vacated slot to nil, the buffer does not keep a
+// reference to the removed item. All calls which remove the oldest
+// items, for example, PopN(), Rotate() and Trim(), use popLocked
+func (cb *CyclicBuffer) popLocked() (interface{}, bool) {
+	if cb.len() == 0 {
+		return nil, false
+	}
+	cb.record(Op{Kind: OpPop})
+	cb.slotRemoved(cb.start)
+	d := cb.data[cb.start]
+	cb.data[cb.start] = nil
+	cb.start++
+	if cb.start >= cb.size {
+		cb.start = 0
+	}
+	cb.full = false
+	cb.version++
+	cb.notFull.Broadcast()
+	return d, true
+}
+
+// Rotate removes the k oldest items from the buffer without reading
+// them. If k exceeds Len() Rotate removes all items
+// Returns the number of removed items
+func (cb *CyclicBuffer) Rotate(k int) int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.rotate(k)
+}
+
+// Trim removes the oldest items, only the newest k items remain
+// The capacity does not change, see also Resize()
+// Returns the number of removed items
+func (cb *CyclicBuffer) Trim(k int) int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if k < 0 {
+		k = 0
+	}
+	return cb.rotate(cb.len() - k)
+}
+
+// rotate removes the k oldest items, the caller holds the mutex
+func (cb *CyclicBuffer) rotate(k int) int {
+	n := 0
+	for ; n < k; n++ {
+		if _, ok := cb.popLocked(); !ok {
+			break
+		}
+	}
+	return n
+}
+
+// PopN removes up to n oldest items from the buffer and returns them,
+// oldest first, in one lock. Returns an empty slice if the buffer
+// is empty
+func (cb *CyclicBuffer) PopN(n int) []interface{} {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	n, _ = cb.clampN(n)
+	res := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		d, _ := cb.popLocked()
+		res = append(res, d)
+	}
+	return res
+}
+
+// PopWait removes the oldest item from the buffer and returns it
+// If the buffer is empty PopWait blocks until Append adds an item
+// or the context is done. Returns ctx.Err() if the context is done
+func (cb *CyclicBuffer) PopWait(ctx context.Context) (interface{}, error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.len() == 0 {
+		defer cb.wakeOnDone(ctx, cb.notEmpty)()
+	}
+	for cb.len() == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cb.notEmpty.Wait()
+	}
+	d, _ := cb.popLocked()
+	return d, nil
+}
+
+// AppendWait adds an item to the buffer. AppendWait never overwrites
+// the oldest item: if the buffer is full AppendWait blocks until Pop()
+// removes an item or the context is done
+// Returns ctx.Err() if the context is done
+func (cb *CyclicBuffer) AppendWait(ctx context.Context, d interface{}) error {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	if cb.size == 0 {
+		return fmt.Errorf("%w: no room in a buffer of size 0", ErrFull)
+	}
+	if cb.full {
+		defer cb.wakeOnDone(ctx, cb.notFull)()
+	}
+	for cb.full {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cb.notFull.Wait()
+	}
+	cb.appendLocked(d)
+	return nil
+}
+
+// wakeOnDone wakes up the waiters on the condition when the context
+// is done. Returns a function which stops the wake up
+// The caller holds the mutex
+func (cb *CyclicBuffer) wakeOnDone(ctx context.Context, cond *sync.Cond) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cb.mutex.Lock()
+			cond.Broadcast()
+			cb.mutex.Unlock()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// Drain removes all items from the buffer and returns them, oldest
+// first. A concurrent Append() is either in the returned items or
+// in the buffer, never in both
+func (cb *CyclicBuffer) Drain() []interface{} {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	res := cb.get()
+	cb.reset()
+	return res
+}
+
+// DrainInto removes up to len(dst) oldest items from the buffer and
+// copies them to dst, oldest first, in one lock. The remaining items
+// stay in the buffer. Returns the number of removed items
+// DrainInto does not allocate memory, I call DrainInto in a flush loop
+func (cb *CyclicBuffer) DrainInto(dst []interface{}) int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	n, _ := cb.clampN(len(dst))
+	for i := 0; i < n; i++ {
+		dst[i], _ = cb.popLocked()
+	}
+	return n
+}
+
+// GetAndClear returns the items, oldest first, and empties the buffer
+// in one lock. I call GetAndClear() in the periodic flush of metrics
+// GetAndClear is the same as Drain()
+func (cb *CyclicBuffer) GetAndClear() []interface{} {
+	return cb.Drain()
+}
+
+// Swap replaces the items in the buffer by a copy of the new items and
+// returns the old items, oldest first, in one lock. The capacity does
+// not change, if there are more new items than the buffer can hold
+// only the newest items remain. I use Swap() for double buffering
+func (cb *CyclicBuffer) Swap(newContents []interface{}) []interface{} {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	res := cb.get()
+	if len(newContents) > cb.size {
+		newContents = newContents[len(newContents)-cb.size:]
+	}
+	cb.load(cb.size, newContents)
+	return res
+}
+
+// AppendSince returns the items appended after the item with the
+// sequence number seq, oldest first, and the sequence number of the
+// newest item. Every Append assigns a sequence number larger than the
+// numbers of the previous items. Call AppendSince(0) to get all items
+// and pass the returned sequence number to the next call
+// The items overwritten before the call are missing
+func (cb *CyclicBuffer) AppendSince(seq uint64) ([]interface{}, uint64) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	res := []interface{}{}
+	count := cb.len()
+	for i := 0; i < count; i++ {
+		index := cb.physical(i)
+		if cb.seqs[index] > seq {
+			res = append(res, cb.data[index])
+		}
+	}
+	return res, cb.seq
+}
+
+// GetIfChanged returns a copy of the items, oldest first, and the
+// version of the buffer if the version differs from lastVersion.
+// Otherwise returns nil and false, the copy is skipped
+// Every call which modifies the items, for example, Append(), Pop()
+// and Set(), changes the version. Pass the returned version to the
+// next call, the version is not a sequence number of AppendSince()
+func (cb *CyclicBuffer) GetIfChanged(lastVersion uint64) (data []interface{}, version uint64, changed bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.version == lastVersion {
+		return nil, cb.version, false
+	}
+	return cb.get(), cb.version, true
+}
+
+// BatchToken identifies the items returned by ReadBatch()
+type BatchToken struct {
+	cb *CyclicBuffer
+	// last is the sequence number of the newest item in the batch
+	last  uint64
+	count int
+}
+
+// Len returns the number of items in the batch
+func (t BatchToken) Len() int {
+	return t.count
+}
+
+// ReadBatch returns up to n oldest items without removing them
+// ReadBatch and Commit() support at least once consumers: the items
+// remain in the buffer until the consumer commits the token
+func (cb *CyclicBuffer) ReadBatch(n int) ([]interface{}, BatchToken) {
+	res, seqs := cb.readBatch(n)
+	if len(res) == 0 {
+		return res, BatchToken{cb: cb}
+	}
+	return res, BatchToken{cb: cb, last: seqs[len(seqs)-1], count: len(res)}
+}
+
+// readBatch returns up to n oldest items and the sequence numbers of
+// the items, see ReadBatch()
+func (cb *CyclicBuffer) readBatch(n int) ([]interface{}, []uint64) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	n, _ = cb.clampN(n)
+	res := cb.copyRange(0, n)
+	seqs := make([]uint64, n)
+	for i := range seqs {
+		seqs[i] = cb.seqs[cb.physical(i)]
+	}
+	return res, seqs
+}
+
+// Commit removes the items returned by ReadBatch()
+// The items which were overwritten or removed since ReadBatch()
+// are skipped
+func (cb *CyclicBuffer) Commit(token BatchToken) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if token.cb != cb || token.last > cb.seq {
+		return fmt.Errorf("%w: the token does not belong to the buffer", ErrInvalidToken)
+	}
+	cb.commit(token.last)
+	return nil
+}
+
+// commit removes the oldest items with the sequence numbers up to last
+// The caller holds the mutex
+func (cb *CyclicBuffer) commit(last uint64) {
+	for cb.len() > 0 && cb.seqs[cb.start] <= last {
+		cb.popLocked()
+	}
+}
@@ -0,0 +1,485 @@
+package cyclicbuffer
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPop(t *testing.T) {
+	cb := New(3)
+	if _, ok := cb.Pop(); ok {
+		t.Fatal()
+	}
+	cb.Append(1)
+	cb.Append(2)
+	if v, _ := cb.Pop(); v != 1 {
+		t.Fatal(v)
+	}
+	cb.Append(3)
+	cb.Append(4)
+	if !reflect.DeepEqual(cb.Get(), []interface{}{2, 3, 4}) || cb.Len() != 3 {
+		t.Fatal(cb.Get())
+	}
+	cb.Append(5)
+	if !reflect.DeepEqual(cb.Get(), []interface{}{3, 4, 5}) {
+		t.Fatal(cb.Get())
+	}
+	if v, _ := cb.Oldest(); v != 3 {
+		t.Fatal(v)
+	}
+	if v, _ := cb.At(2); v != 5 {
+		t.Fatal(v)
+	}
+	for _, e := range []int{3, 4, 5} {
+		if v, ok := cb.Pop(); !ok || v != e {
+			t.Fatal(v)
+		}
+	}
+	if cb.Len() != 0 {
+		t.Fatal(cb.Len())
+	}
+	it := cb.CreateIterator()
+	if it.Next() {
+		t.Fatal()
+	}
+	cb.Append(6)
+	if !reflect.DeepEqual(cb.Get(), []interface{}{6}) {
+		t.Fatal(cb.Get())
+	}
+	it = cb.CreateIterator()
+	if !it.Next() || it.Value() != 6 || it.Next() {
+		t.Fatal()
+	}
+}
+
+func TestPopWait(t *testing.T) {
+	cb := New(3)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cb.Append(7)
+	}()
+	v, err := cb.PopWait(context.Background())
+	if err != nil || v != 7 {
+		t.Fatal(v, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = cb.PopWait(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+	cb.Append(1)
+	// the item wins over the done context
+	if v, err := cb.PopWait(ctx); err != nil || v != 1 {
+		t.Fatal(v, err)
+	}
+}
+
+func TestEmptyAfterPop(t *testing.T) {
+	cb := New(3)
+	if !cb.Empty() {
+		t.Fatal()
+	}
+	cb.Append(1)
+	cb.Append(2)
+	cb.Pop()
+	cb.Pop()
+	if !cb.Empty() || cb.NotEmpty() {
+		t.Fatal()
+	}
+	for i := 0; i < 3; i++ {
+		cb.Append(i)
+	}
+	if cb.Empty() {
+		t.Fatal()
+	}
+}
+
+func TestDrain(t *testing.T) {
+	const N = 100000
+	cb := New(N)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < N; i++ {
+			cb.Append(i)
+		}
+		close(done)
+	}()
+	seen := make(map[int]bool)
+	fin := false
+	for !fin {
+		select {
+		case <-done:
+			fin = true
+		default:
+		}
+		for _, v := range cb.Drain() {
+			if seen[v.(int)] {
+				t.Fatal(v)
+			}
+			seen[v.(int)] = true
+		}
+	}
+	if len(seen) != N || !cb.Empty() {
+		t.Fatal(len(seen))
+	}
+}
+
+func TestReadBatch(t *testing.T) {
+	cb := New(5)
+	for i := 0; i < 4; i++ {
+		cb.Append(i)
+	}
+	b, tok := cb.ReadBatch(2)
+	if len(b) != 2 || b[0] != 0 || tok.Len() != 2 {
+		t.Fatal(b)
+	}
+	b2, _ := cb.ReadBatch(2)
+	if b2[0] != 0 {
+		t.Fatal()
+	}
+	if err := cb.Commit(tok); err != nil {
+		t.Fatal(err)
+	}
+	if g := cb.Get(); len(g) != 2 || g[0] != 2 {
+		t.Fatal(g)
+	}
+	// batch partially evicted before commit
+	_, tok = cb.ReadBatch(2)
+	for i := 4; i < 8; i++ {
+		cb.Append(i)
+	}
+	// [3..7], token covers 2,3
+	cb.Commit(tok)
+	if g := cb.Get(); len(g) != 4 || g[0] != 4 {
+		t.Fatal(g)
+	}
+	if cb.Commit(BatchToken{}) == nil {
+		t.Fatal()
+	}
+	e, tok := New(2).ReadBatch(3)
+	if len(e) != 0 || tok.Len() != 0 {
+		t.Fatal()
+	}
+	cb.Resize(10)
+	_, tok = cb.ReadBatch(1)
+	cb.Commit(tok)
+	if g := cb.Get(); len(g) != 3 || g[0] != 5 {
+		t.Fatal(g)
+	}
+}
+
+func TestGetAndClear(t *testing.T) {
+	cb := New(64)
+	const N = 3000
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < N; i++ {
+			for !cb.AppendIfNotFull(i) {
+				runtime.Gosched()
+			}
+		}
+		close(done)
+	}()
+	next := 0
+	fin := false
+	for !fin {
+		select {
+		case <-done:
+			fin = true
+		default:
+		}
+		for _, v := range cb.GetAndClear() {
+			if v != next {
+				t.Fatal(v, next)
+			}
+			next++
+		}
+		runtime.Gosched()
+	}
+	if next != N {
+		t.Fatal(next)
+	}
+}
+
+func TestAppendWait(t *testing.T) {
+	cb := New(2)
+	cb.Append(1)
+	cb.Append(2)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cb.Pop()
+	}()
+	if err := cb.AppendWait(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if g := cb.Get(); g[0] != 2 || g[1] != 3 {
+		t.Fatal(g)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := cb.AppendWait(ctx, 4); err != context.DeadlineExceeded {
+		t.Fatal(err)
+	}
+	if g := cb.Get(); g[0] != 2 || g[1] != 3 {
+		t.Fatal(g)
+	}
+	if New(0).AppendWait(ctx, 1) == nil {
+		t.Fatal()
+	}
+}
+
+func TestRotate(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if n := cb.Rotate(1); n != 1 || cb.Len() != 3 || cb.Get()[0] != 3 {
+		t.Fatal(n, cb.Get())
+	}
+	if n := cb.Rotate(3); n != 3 || cb.Len() != 0 {
+		t.Fatal(n)
+	}
+	cb.Append(6)
+	cb.Append(7)
+	if n := cb.Rotate(5); n != 2 || cb.Len() != 0 {
+		t.Fatal(n)
+	}
+	if n := cb.Rotate(-1); n != 0 {
+		t.Fatal(n)
+	}
+	cb.Append(8)
+	if g := cb.Get(); len(g) != 1 || g[0] != 8 {
+		t.Fatal(g)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	cb := New(3)
+	cb.Append(0)
+	cb.Append(1)
+	if old := cb.Swap([]interface{}{2, 3, 4, 5}); len(old) != 2 || old[1] != 1 {
+		t.Fatal(old)
+	}
+	if g := cb.Get(); len(g) != 3 || g[0] != 3 || g[2] != 5 || !cb.Full() {
+		t.Fatal(g)
+	}
+	cb.Append(6)
+	if g := cb.Get(); g[0] != 4 || g[2] != 6 {
+		t.Fatal(g)
+	}
+
+	c := New(1000)
+	done := make(chan struct{})
+	const N = 10000
+	go func() {
+		defer close(done)
+		for i := 0; i < N; i++ {
+			c.Append(i)
+		}
+	}()
+	var got []interface{}
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		got = append(got, c.Swap(nil)...)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].(int) <= got[i-1].(int) {
+			t.Fatal(got[i-1], got[i])
+		}
+	}
+	if got[len(got)-1] != N-1 {
+		t.Fatal(got[len(got)-1])
+	}
+}
+
+func TestAppendSince(t *testing.T) {
+	cb := New(3)
+	items, seq := cb.AppendSince(0)
+	if len(items) != 0 || seq != 0 {
+		t.Fatal(items, seq)
+	}
+	cb.Append(0)
+	cb.Append(1)
+	items, seq = cb.AppendSince(seq)
+	if len(items) != 2 {
+		t.Fatal(items, seq)
+	}
+	cb.Append(2)
+	items, seq = cb.AppendSince(seq)
+	if len(items) != 1 || items[0] != 2 {
+		t.Fatal(items, seq)
+	}
+	for i := 3; i < 8; i++ {
+		cb.Append(i)
+	}
+	items, seq = cb.AppendSince(seq)
+	if len(items) != 3 || items[0] != 5 {
+		t.Fatal(items, seq)
+	}
+	if items, _ = cb.AppendSince(seq); len(items) != 0 {
+		t.Fatal(items)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if n := cb.Trim(10); n != 0 || cb.Len() != 4 {
+		t.Fatal(n)
+	}
+	if n := cb.Trim(1); n != 3 || cb.Cap() != 4 {
+		t.Fatal(n)
+	}
+	if g := cb.Get(); len(g) != 1 || g[0] != 5 {
+		t.Fatal(g)
+	}
+	for i := 6; i < 9; i++ {
+		cb.Append(i)
+	}
+	if g := cb.Get(); len(g) != 4 || g[0] != 5 || g[3] != 8 {
+		t.Fatal(g)
+	}
+	if n := cb.Trim(-1); n != 4 || cb.Len() != 0 {
+		t.Fatal(n)
+	}
+}
+
+func TestPopN(t *testing.T) {
+	cb := New(4)
+	if r := cb.PopN(2); r == nil || len(r) != 0 {
+		t.Fatal(r)
+	}
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	if r := cb.PopN(1); len(r) != 1 || r[0] != 2 {
+		t.Fatal(r)
+	}
+	if r := cb.PopN(3); len(r) != 3 || r[0] != 3 || r[2] != 5 {
+		t.Fatal(r)
+	}
+	cb.Append(6)
+	cb.Append(7)
+	if r := cb.PopN(5); len(r) != 2 || r[1] != 7 || cb.Len() != 0 {
+		t.Fatal(r)
+	}
+	if r := cb.PopN(-1); len(r) != 0 {
+		t.Fatal(r)
+	}
+}
+
+func TestGetIfChanged(t *testing.T) {
+	cb := New(3)
+	data, seq, changed := cb.GetIfChanged(0)
+	if changed || data != nil {
+		t.Fatal(data)
+	}
+	cb.Append(1)
+	data, seq, changed = cb.GetIfChanged(seq)
+	if !changed || len(data) != 1 {
+		t.Fatal(data)
+	}
+	if data, _, changed = cb.GetIfChanged(seq); changed || data != nil {
+		t.Fatal(data)
+	}
+	cb.Append(2)
+	data, seq, changed = cb.GetIfChanged(seq)
+	if !changed || len(data) != 2 || data[1] != 2 {
+		t.Fatal(data)
+	}
+	// The calls which do not add items change the version
+	for _, modify := range []func(){
+		func() { cb.Pop() },
+		func() { cb.Set(0, 3) },
+		func() { cb.Append(4); cb.Compact() },
+		func() { cb.Resize(4) },
+		func() { cb.Clear() },
+	} {
+		modify()
+		data, seq, changed = cb.GetIfChanged(seq)
+		if !changed || !reflect.DeepEqual(data, cb.Get()) {
+			t.Fatal(data)
+		}
+	}
+	if _, _, changed = cb.GetIfChanged(seq); changed {
+		t.Fatal()
+	}
+}
+
+func TestReleaseReferences(t *testing.T) {
+	cb := New(8)
+	var collected int32
+	for i := 0; i < 8; i++ {
+		p := new([1 << 10]byte)
+		runtime.SetFinalizer(p, func(*[1 << 10]byte) { atomic.AddInt32(&collected, 1) })
+		cb.Append(p)
+	}
+	// Every call releases the removed items before the next call
+	check := func(op string, collect int32) {
+		t.Helper()
+		for i := 0; i < 50 && atomic.LoadInt32(&collected) < collect; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		if c := atomic.LoadInt32(&collected); c != collect {
+			t.Fatal(op, c)
+		}
+		stored := 0
+		for _, d := range cb.AllSlots() {
+			if d != nil {
+				stored++
+			}
+		}
+		if stored != cb.Len() {
+			t.Fatal(op, stored, cb.Len())
+		}
+	}
+	cb.Pop()
+	check("Pop", 1)
+	cb.PopN(2)
+	check("PopN", 3)
+	cb.Rotate(1)
+	check("Rotate", 4)
+	cb.Trim(2)
+	check("Trim", 6)
+	cb.Drain()
+	check("Drain", 8)
+}
+
+func TestDrainInto(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 6; i++ {
+		cb.Append(i)
+	}
+	dst := make([]interface{}, 3)
+	if n := cb.DrainInto(dst); n != 3 || dst[0] != 2 || dst[2] != 4 || cb.Len() != 1 {
+		t.Fatal(n, dst)
+	}
+	if s := cb.AllSlots(); s[2] != nil {
+		t.Fatal(s)
+	}
+	cb.Append(6)
+	dst = make([]interface{}, 2)
+	if n := cb.DrainInto(dst); n != 2 || dst[0] != 5 || dst[1] != 6 || cb.Len() != 0 {
+		t.Fatal(n, dst)
+	}
+	cb.Append(7)
+	dst = make([]interface{}, 5)
+	if n := cb.DrainInto(dst); n != 1 || dst[0] != 7 || dst[1] != nil {
+		t.Fatal(n, dst)
+	}
+	if n := cb.DrainInto(nil); n != 0 {
+		t.Fatal(n)
+	}
+}
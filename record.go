@@ -0,0 +1,101 @@
+package cyclicbuffer
+
+// Op is an operation in the log, see RecordOps()
+type Op struct {
+	Kind string
+	// Value is the appended item, the new item of OpSet or the items
+	// of OpLoad, a []interface{}
+	Value interface{}
+	// Size is the size of the buffer
+	Size int
+	// Index is the logical position of OpSet or the slot of the oldest
+	// item of OpLoad
+	Index int
+}
+
+// Operations in the log, see RecordOps()
+const (
+	OpNew    = "new"
+	OpAppend = "append"
+	OpPop    = "pop"
+	OpResize = "resize"
+	OpClear  = "clear"
+	// OpSet replaces the item at the logical position, see Set()
+	OpSet = "set"
+	// OpReplaceNewest replaces the newest item, see ReplaceNewest()
+	OpReplaceNewest = "replace_newest"
+	// OpCompact removes the nil items, see Compact()
+	OpCompact = "compact"
+	// OpLoad replaces all items and the size, for example, Swap() and
+	// UnmarshalBinary() load the items
+	OpLoad = "load"
+)
+
+// RecordOps enables or disables the log of the operations
+// Enabling the log drops the previous log and records the current
+// state of the buffer: OpNew and OpAppend for every item. After that
+// the log gets every Append, Pop, Resize, Clear, Set, ReplaceNewest,
+// Compact and Load, including
+// the operations which call them, for example, Rotate() pops the items
+// and Swap() loads the items. Replay() of the log produces the same items
+// I use the log to reproduce the failures of the fuzz tests, see Replay()
+// The log does not keep the options, for example, SetUniqueKey()
+func (cb *CyclicBuffer) RecordOps(enable bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.recording = enable
+	cb.ops = nil
+	if !enable {
+		return
+	}
+	cb.ops = append(cb.ops, Op{Kind: OpNew, Size: cb.size})
+	cb.walk(func(_ int, d interface{}) bool {
+		cb.ops = append(cb.ops, Op{Kind: OpAppend, Value: d})
+		return true
+	})
+}
+
+// Ops returns a copy of the log of the operations
+func (cb *CyclicBuffer) Ops() []Op {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return append([]Op{}, cb.ops...)
+}
+
+// record adds the operation to the log, the caller holds the mutex
+func (cb *CyclicBuffer) record(op Op) {
+	if cb.recording {
+		cb.ops = append(cb.ops, op)
+	}
+}
+
+// Replay applies the operations to a new buffer and returns the buffer
+// If the first operation is not OpNew the buffer has size 0
+func Replay(ops []Op) *CyclicBuffer {
+	cb := New(0)
+	for _, op := range ops {
+		switch op.Kind {
+		case OpNew:
+			cb = New(op.Size)
+		case OpAppend:
+			cb.Append(op.Value)
+		case OpPop:
+			cb.Pop()
+		case OpResize:
+			cb.Resize(op.Size)
+		case OpClear:
+			cb.Clear()
+		case OpSet:
+			cb.Set(op.Index, op.Value)
+		case OpReplaceNewest:
+			cb.ReplaceNewest(op.Value)
+		case OpCompact:
+			cb.Compact()
+		case OpLoad:
+			cb.mutex.Lock()
+			cb.loadAt(op.Size, op.Index, op.Value.([]interface{}))
+			cb.mutex.Unlock()
+		}
+	}
+	return cb
+}
@@ -0,0 +1,115 @@
+package cyclicbuffer
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	cb := New(4)
+	cb.Append(-1)
+	cb.Append(-2)
+	cb.RecordOps(true)
+	cb.SetAutoGrow(true)
+	for i := 0; i < 1000; i++ {
+		switch r.Intn(8) {
+		case 0:
+			cb.Pop()
+		case 1:
+			cb.Resize(r.Intn(6))
+		case 2:
+			cb.Rotate(2)
+		case 3:
+			if r.Intn(10) == 0 {
+				cb.Clear()
+			}
+		case 4:
+			if cb.Cap() > 20 {
+				cb.SetAutoGrow(false)
+			}
+		default:
+			cb.Append(i)
+		}
+		if i%100 == 0 {
+			c := Replay(cb.Ops())
+			if !c.Equal(cb, nil) || c.Cap() != cb.Cap() {
+				t.Fatal(i, c.Get(), cb.Get())
+			}
+		}
+	}
+	ops := cb.Ops()
+	if ops[0].Kind != OpNew || ops[0].Size != 4 || ops[1].Value != -1 {
+		t.Fatal(ops[:3])
+	}
+	c := Replay(ops)
+	if !c.Equal(cb, nil) || c.Cap() != cb.Cap() || c.Validate() != nil {
+		t.Fatal(c.Get(), cb.Get())
+	}
+	cb.RecordOps(false)
+	cb.Append(1)
+	if len(cb.Ops()) != 0 || Replay(nil).Cap() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestReplaySetLoad(t *testing.T) {
+	cb := New(3)
+	cb.RecordOps(true)
+	cb.Append(1)
+	cb.Append(2)
+	cb.Set(0, 3)
+	cb.Swap([]interface{}{5, 6})
+	cb.Append(7)
+	cb.Set(1, 8)
+	b, _ := cb.MarshalJSON()
+	r := Replay(cb.Ops())
+	if !reflect.DeepEqual(cb.Get(), r.Get()) || cb.Cap() != r.Cap() {
+		t.Fatal(cb.Get(), r.Get())
+	}
+	cb.UnmarshalJSON(b)
+	cb.Append(9)
+	r = Replay(cb.Ops())
+	if !reflect.DeepEqual(cb.Get(), r.Get()) {
+		t.Fatal(cb.Get(), r.Get())
+	}
+}
+
+func TestReplayCompact(t *testing.T) {
+	cb := New(4)
+	cb.RecordOps(true)
+	for _, d := range []interface{}{1, nil, 2, nil, 3} {
+		cb.Append(d)
+	}
+	cb.Compact()
+	cb.Append(4)
+	if r := Replay(cb.Ops()); !reflect.DeepEqual(r.Get(), cb.Get()) {
+		t.Fatal(r.Get(), cb.Get())
+	}
+}
+
+func TestReplayAllOps(t *testing.T) {
+	cb := New(3)
+	cb.RecordOps(true)
+	cb.Append(1)
+	cb.Append(2)
+	cb.Set(0, 3)
+	cb.ReplaceNewest(4)
+	cb.Append(nil)
+	cb.Compact()
+	cb.Swap([]interface{}{5})
+	cb.Append(6)
+	cb.Set(1, 7)
+	r := Replay(cb.Ops())
+	if !reflect.DeepEqual(cb.Get(), r.Get()) || cb.Cap() != r.Cap() {
+		t.Fatal(cb.Get(), r.Get())
+	}
+	b, _ := cb.MarshalJSON()
+	cb.UnmarshalJSON(b)
+	cb.Append(8)
+	r = Replay(cb.Ops())
+	if !reflect.DeepEqual(cb.Get(), r.Get()) {
+		t.Fatal(cb.Get(), r.Get())
+	}
+}
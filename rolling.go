@@ -0,0 +1,60 @@
+package cyclicbuffer
+
+// SetRollingSum sets a function which returns the value of an item
+// If the function is set the buffer keeps the sum of the values of the
+// stored items: Append adds the value of the new item and subtracts
+// the value of the overwritten item, see RollingSum()
+// The sum accumulates the rounding errors of float64, the operations
+// which move the items, for example, Resize(), compute the sum again
+// The function is called while the buffer is locked, the function
+// shall not call the buffer API. Set nil to disable
+func (cb *CyclicBuffer) SetRollingSum(extract func(interface{}) float64) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.extract = extract
+	cb.rollingRebuild()
+}
+
+// RollingSum returns the sum of the values of the stored items in O(1)
+// Returns 0 if the function is not set, see SetRollingSum()
+func (cb *CyclicBuffer) RollingSum() float64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.rollingSum
+}
+
+// rollingAdd adds the value of the item in the slot to the sum
+// The caller holds the mutex
+func (cb *CyclicBuffer) rollingAdd(slot int) {
+	if cb.extract != nil {
+		cb.rollingSum += cb.value(cb.data[slot])
+	}
+}
+
+// rollingRemove subtracts the value of the item in the slot from
+// the sum. The caller holds the mutex
+func (cb *CyclicBuffer) rollingRemove(slot int) {
+	if cb.extract != nil {
+		cb.rollingSum -= cb.value(cb.data[slot])
+	}
+}
+
+// rollingRebuild computes the sum from scratch
+// The caller holds the mutex
+func (cb *CyclicBuffer) rollingRebuild() {
+	cb.rollingSum = 0
+	if cb.extract == nil {
+		return
+	}
+	count := cb.len()
+	for i := 0; i < count; i++ {
+		cb.rollingAdd(cb.physical(i))
+	}
+}
+
+// value returns extract(d), 0 if extract panics, see SetPanicHandler()
+// The caller holds the mutex
+func (cb *CyclicBuffer) value(d interface{}) (v float64) {
+	cb.call(func() { v = cb.extract(d) })
+	return v
+}
@@ -0,0 +1,62 @@
+package cyclicbuffer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRollingSum(t *testing.T) {
+	cb := New(7)
+	cb.Append(100)
+	cb.SetRollingSum(func(d interface{}) float64 { return float64(d.(int)) })
+	if cb.RollingSum() != 100 {
+		t.Fatal(cb.RollingSum())
+	}
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 1000; i++ {
+		switch r.Intn(10) {
+		case 0:
+			cb.Pop()
+		case 1:
+			cb.Set(0, r.Intn(10))
+		case 2:
+			if r.Intn(10) == 0 {
+				cb.Resize(r.Intn(9) + 1)
+			}
+		default:
+			cb.Append(r.Intn(100))
+		}
+		var sum float64
+		for _, d := range cb.Get() {
+			sum += float64(d.(int))
+		}
+		if sum != cb.RollingSum() {
+			t.Fatal(i, sum, cb.RollingSum())
+		}
+	}
+	cb.Clear()
+	if cb.RollingSum() != 0 {
+		t.Fatal()
+	}
+	cb.SetRollingSum(nil)
+	cb.Append(1)
+	if cb.RollingSum() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestRollingSumAppendReuse(t *testing.T) {
+	type item struct{ v float64 }
+	cb := New(2)
+	cb.SetRollingSum(func(d interface{}) float64 { return d.(*item).v })
+	cb.Append(&item{1})
+	cb.Append(&item{2})
+	cb.AppendReuse(func(old interface{}) interface{} {
+		it := old.(*item)
+		it.v = 10
+		return it
+	})
+	if cb.RollingSum() != 12 {
+		t.Fatal(cb.RollingSum())
+	}
+}
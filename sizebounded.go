@@ -0,0 +1,55 @@
+package cyclicbuffer
+
+// NewSizeBounded creates a buffer which limits the total size of the
+// items instead of the number of the items. sizeOf returns the size of
+// an item, for example, the length of a string. Append removes the
+// oldest items until the total size of the items and the new item fits
+// maxBytes. An item larger than maxBytes removes all other items and
+// remains in the buffer alone. ReplaceNewest() removes the oldest items
+// the same way, Set() returns ErrFull if the item does not fit
+// The buffer uses the Grow policy, Cap() grows as needed
+// sizeOf is called while the buffer is locked, sizeOf shall not call
+// the buffer API
+func NewSizeBounded(maxBytes int, sizeOf func(interface{}) int) *CyclicBuffer {
+	cb := NewWithPolicy(0, Grow)
+	cb.sizeOf = sizeOf
+	cb.byteLimit = maxBytes
+	return cb
+}
+
+// ByteLen returns the total size of the stored items, see
+// NewSizeBounded(). Returns 0 for other buffers
+func (cb *CyclicBuffer) ByteLen() int {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.byteLen
+}
+
+// makeRoom removes the oldest items until an item of the size fits
+// the limit. The newest keep items remain. The caller holds the mutex
+func (cb *CyclicBuffer) makeRoom(size int, keep int) {
+	for cb.len() > keep && cb.byteLen+size > cb.byteLimit {
+		cb.evicted(cb.data[cb.start])
+		cb.popLocked()
+	}
+}
+
+// byteLenRebuild computes the total size of the items from scratch
+// The caller holds the mutex
+func (cb *CyclicBuffer) byteLenRebuild() {
+	cb.byteLen = 0
+	if cb.sizeOf == nil {
+		return
+	}
+	count := cb.len()
+	for i := 0; i < count; i++ {
+		cb.byteLen += cb.itemSize(cb.data[cb.physical(i)])
+	}
+}
+
+// itemSize returns sizeOf(d), 0 if sizeOf panics, see SetPanicHandler()
+// The caller holds the mutex
+func (cb *CyclicBuffer) itemSize(d interface{}) (size int) {
+	cb.call(func() { size = cb.sizeOf(d) })
+	return size
+}
@@ -0,0 +1,108 @@
+package cyclicbuffer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSizeBounded(t *testing.T) {
+	cb := NewSizeBounded(10, func(d interface{}) int { return len(d.(string)) })
+	var evicted []interface{}
+	cb.SetEvictionHandler(func(d interface{}) { evicted = append(evicted, d) })
+	for _, s := range []string{"aaaa", "bbb", "cc", "d", "eeeee"} {
+		cb.Append(s)
+		if cb.ByteLen() > 10 {
+			t.Fatal(cb.ByteLen())
+		}
+	}
+	// aaaa bbb cc d = 10, eeeee removes aaaa and bbb
+	if g := cb.Get(); len(g) != 3 || g[0] != "cc" || g[2] != "eeeee" || cb.ByteLen() != 8 {
+		t.Fatal(g, cb.ByteLen())
+	}
+	if len(evicted) != 2 || evicted[0] != "aaaa" || evicted[1] != "bbb" {
+		t.Fatal(evicted)
+	}
+	cb.Append("0123456789ab")
+	if g := cb.Get(); len(g) != 1 || cb.ByteLen() != 12 {
+		t.Fatal(g, cb.ByteLen())
+	}
+	cb.Append("x")
+	if g := cb.Get(); len(g) != 1 || g[0] != "x" || cb.ByteLen() != 1 {
+		t.Fatal(g)
+	}
+	cb.Pop()
+	if cb.ByteLen() != 0 || New(3).ByteLen() != 0 {
+		t.Fatal()
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSizeBoundedReplace(t *testing.T) {
+	cb := NewSizeBounded(10, func(d interface{}) int { return len(d.(string)) })
+	var evicted []interface{}
+	cb.SetEvictionHandler(func(d interface{}) { evicted = append(evicted, d) })
+	cb.Append("aaaa")
+	cb.Append("bbbb")
+	if err := cb.Set(0, "cccccccc"); !errors.Is(err, ErrFull) {
+		t.Fatal(err)
+	}
+	if err := cb.Set(0, "cccccc"); err != nil || cb.ByteLen() != 10 {
+		t.Fatal(err, cb.ByteLen())
+	}
+	cb.ReplaceNewest("dddddddd")
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{"dddddddd"}) || cb.ByteLen() != 8 {
+		t.Fatal(got, cb.ByteLen())
+	}
+	if !reflect.DeepEqual(evicted, []interface{}{"cccccc"}) {
+		t.Fatal(evicted)
+	}
+	// An item larger than the limit remains alone
+	cb.Append("ee")
+	cb.ReplaceNewest("ffffffffffff")
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{"ffffffffffff"}) || cb.ByteLen() != 12 {
+		t.Fatal(got, cb.ByteLen())
+	}
+	if err := cb.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSizeBoundedClone(t *testing.T) {
+	cb := NewSizeBounded(3, func(d interface{}) int { return 1 })
+	cb.Append(1)
+	c := cb.Clone()
+	for i := 0; i < 5; i++ {
+		c.Append(i)
+	}
+	if c.Len() != 3 || c.ByteLen() != 3 {
+		t.Fatal(c.Len())
+	}
+}
+
+func TestSizeBoundedAppendReuse(t *testing.T) {
+	sb := NewSizeBounded(10, func(d interface{}) int { return len(*d.(*string)) })
+	s1, s2 := "aaaa", "bbbb"
+	sb.Append(&s1)
+	sb.Append(&s2)
+	sb.SetAutoGrow(false)
+	sb.AppendReuse(func(old interface{}) interface{} {
+		p := old.(*string)
+		*p = "cc"
+		return p
+	})
+	if sb.ByteLen() != 6 {
+		t.Fatal(sb.ByteLen(), sb.Len())
+	}
+}
+
+func TestSizeBoundedPanic(t *testing.T) {
+	sb := NewSizeBounded(10, func(d interface{}) int { panic("size") })
+	sb.SetPanicHandler(func(interface{}) {})
+	sb.Append(1)
+	if sb.Len() != 1 || sb.ByteLen() != 0 {
+		t.Fatal()
+	}
+}
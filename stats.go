@@ -0,0 +1,25 @@
+package cyclicbuffer
+
+// Stats returns the number of items appended since the buffer was
+// created and the number of items overwritten by Append
+func (cb *CyclicBuffer) Stats() (appended, evicted uint64) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.totalAppended, cb.totalEvicted
+}
+
+// WrapCount returns the number of times Append reached the end of the
+// internal array and continued from the slot 0
+func (cb *CyclicBuffer) WrapCount() uint64 {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.wraps
+}
+
+// State returns the position of the next entry, the capacity and the
+// full flag in one lock. I use State() in the race tests
+func (cb *CyclicBuffer) State() (index, size int, full bool) {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.index, cb.size, cb.full
+}
@@ -0,0 +1,56 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 8; i++ {
+		cb.Append(i)
+	}
+	cb.Pop()
+	cb.Append(1)
+	a, e := cb.Stats()
+	if a != 9 || e != 5 {
+		t.Fatal(a, e)
+	}
+}
+
+func TestWrapCount(t *testing.T) {
+	cb := New(4)
+	for i := 0; i < 4*3+1; i++ {
+		cb.Append(i)
+	}
+	if cb.WrapCount() != 3 || cb.Clone().WrapCount() != 3 {
+		t.Fatal(cb.WrapCount())
+	}
+	if New(0).WrapCount() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestState(t *testing.T) {
+	cb := New(7)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000; i++ {
+			cb.Append(i)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		index, size, full := cb.State()
+		if size != 7 || index < 0 || index >= size {
+			t.Fatal(index, size, full)
+		}
+	}
+	if _, _, full := cb.State(); !full {
+		t.Fatal()
+	}
+}
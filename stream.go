@@ -0,0 +1,136 @@
+package cyclicbuffer
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTo writes the items, oldest first, to w
+// WriteTo takes a snapshot of the buffer and calls encode for every item
+// Returns the number of written bytes and the first error
+func (cb *CyclicBuffer) WriteTo(w io.Writer, encode func(interface{}) ([]byte, error)) (int64, error) {
+	var total int64
+	for _, d := range cb.Get() {
+		b, err := encode(d)
+		if err != nil {
+			return total, err
+		}
+		n, err := w.Write(b)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// EachJSON writes the items, oldest first, to w, one JSON document
+// per line (NDJSON). Returns the first marshal or write error
+func (cb *CyclicBuffer) EachJSON(w io.Writer) error {
+	_, err := cb.WriteTo(w, func(d interface{}) ([]byte, error) {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	})
+	return err
+}
+
+// FlushTo removes the items from the buffer, oldest first, and writes
+// them to w, up to batch items in one lock, until the buffer is empty
+// FlushTo removes an item after the item is written: if encode or w
+// fails FlushTo removes the items written before the failure and stops,
+// the failed item remains in the buffer and the next FlushTo writes the
+// item again. Returns the number of the written items and the first error
+func (cb *CyclicBuffer) FlushTo(w io.Writer, batch int, encode func(interface{}) ([]byte, error)) (int, error) {
+	if batch < 1 {
+		batch = 1
+	}
+	flushed := 0
+	for {
+		items, seqs := cb.readBatch(batch)
+		if len(items) == 0 {
+			return flushed, nil
+		}
+		for i, d := range items {
+			if err := writeItem(w, d, encode); err != nil {
+				if i > 0 {
+					cb.Commit(BatchToken{cb: cb, last: seqs[i-1]})
+				}
+				return flushed + i, err
+			}
+		}
+		cb.Commit(BatchToken{cb: cb, last: seqs[len(seqs)-1]})
+		flushed += len(items)
+	}
+}
+
+// writeItem encodes the item and writes it to w
+func writeItem(w io.Writer, d interface{}, encode func(interface{}) ([]byte, error)) error {
+	b, err := encode(d)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ToCSV returns the items, oldest first, as one CSV line without the
+// line terminator. Every item is a field, field(item) returns the text
+// of the field. The fields which contain commas, quotes or new lines
+// are quoted as in RFC 4180
+// ToCSV calls field after releasing the lock, field can call the buffer API
+func (cb *CyclicBuffer) ToCSV(field func(interface{}) string) string {
+	items := cb.Get()
+	record := make([]string, 0, len(items))
+	for _, d := range items {
+		record = append(record, field(d))
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	// strings.Builder does not fail
+	w.Write(record)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ReadFramesFrom reads the records from r until io.EOF and appends
+// decode(payload) for every record. The format of the records is the
+// format of ByteBuffer.WriteRecord(): the length of the payload, 4
+// bytes big endian, and the payload. The items are added by Append(),
+// if there are more records than the buffer can hold only the newest
+// remain. Returns the number of read bytes and the first error, the
+// clean io.EOF between the records is not an error
+// A record larger than maxFrame bytes is an error, the limit protects
+// from the corrupted streams
+// ReadFramesFrom does not hold the lock while reading from r
+func (cb *CyclicBuffer) ReadFramesFrom(r io.Reader, maxFrame int, decode func([]byte) interface{}) (int64, error) {
+	var total int64
+	var header [recordHeader]byte
+	for {
+		n, err := io.ReadFull(r, header[:])
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, fmt.Errorf("%w: truncated record header: %w", ErrInvalidSize, err)
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if int64(length) > int64(maxFrame) {
+			return total, fmt.Errorf("%w: record of %d bytes exceeds %d", ErrInvalidSize, length, maxFrame)
+		}
+		payload := make([]byte, length)
+		n, err = io.ReadFull(r, payload)
+		total += int64(n)
+		if err != nil {
+			return total, fmt.Errorf("%w: truncated record of %d bytes: %w", ErrInvalidSize, len(payload), err)
+		}
+		cb.Append(decode(payload))
+	}
+}
@@ -0,0 +1,139 @@
+package cyclicbuffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	cb := New(3)
+	for i := 0; i < 5; i++ {
+		cb.Append(i)
+	}
+	var buf bytes.Buffer
+	n, err := cb.WriteTo(&buf, func(d interface{}) ([]byte, error) { return []byte(fmt.Sprint(d)), nil })
+	if err != nil || n != 3 || buf.String() != "234" {
+		t.Fatal(n, err, buf.String())
+	}
+	e := errors.New("x")
+	n, err = cb.WriteTo(&buf, func(d interface{}) ([]byte, error) {
+		if d == 3 {
+			return nil, e
+		}
+		return []byte("a"), nil
+	})
+	if err != e || n != 1 {
+		t.Fatal(n, err)
+	}
+}
+
+func TestEachJSON(t *testing.T) {
+	cb := New(3)
+	for _, v := range []interface{}{1, "a", map[string]int{"x": 1}, []int{2}} {
+		cb.Append(v)
+	}
+	var buf bytes.Buffer
+	if err := cb.EachJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var want string
+	for _, d := range cb.Get() {
+		b, _ := json.Marshal(d)
+		want += string(b) + "\n"
+	}
+	if buf.String() != want {
+		t.Fatal(buf.String())
+	}
+	cb.Append(func() {})
+	if cb.EachJSON(&buf) == nil {
+		t.Fatal()
+	}
+}
+
+type failingWriter struct {
+	n int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if fw.n == 0 {
+		return 0, errors.New("full")
+	}
+	fw.n--
+	return len(p), nil
+}
+
+func TestFlushTo(t *testing.T) {
+	cb := New(7)
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	enc := func(d interface{}) ([]byte, error) { return []byte(fmt.Sprint(d)), nil }
+	var buf bytes.Buffer
+	if n, err := cb.FlushTo(&buf, 3, enc); n != 7 || err != nil || buf.String() != "0123456" || cb.Len() != 0 {
+		t.Fatal(n, err, buf.String())
+	}
+	for i := 0; i < 7; i++ {
+		cb.Append(i)
+	}
+	if n, err := cb.FlushTo(&failingWriter{n: 4}, 3, enc); n != 4 || err == nil || cb.Len() != 3 {
+		t.Fatal(n, err, cb.Len())
+	}
+	buf.Reset()
+	if n, err := cb.FlushTo(&buf, 0, enc); n != 3 || err != nil || buf.String() != "456" {
+		t.Fatal(n, err, buf.String())
+	}
+}
+
+func TestToCSV(t *testing.T) {
+	cb := New(5)
+	for _, v := range []string{"a", "b,c", `say "hi"`, "two\nlines", ""} {
+		cb.Append(v)
+	}
+	got := cb.ToCSV(func(d interface{}) string { return d.(string) })
+	want := "a,\"b,c\",\"say \"\"hi\"\"\",\"two\nlines\","
+	if got != want {
+		t.Fatalf("%q", got)
+	}
+	if New(2).ToCSV(nil) != "" {
+		t.Fatal()
+	}
+}
+
+func TestReadFramesFrom(t *testing.T) {
+	bb := NewByteBuffer(100)
+	for _, s := range []string{"a", "", "bcd", "ef"} {
+		if err := bb.WriteRecord([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw := bb.Bytes()
+	cb := New(3)
+	n, err := cb.ReadFramesFrom(bytes.NewReader(raw), 100, func(p []byte) interface{} { return string(p) })
+	if err != nil || n != int64(len(raw)) {
+		t.Fatal(n, err)
+	}
+	if got := cb.Get(); !reflect.DeepEqual(got, []interface{}{"", "bcd", "ef"}) {
+		t.Fatal(got)
+	}
+	cb = New(3)
+	_, err = cb.ReadFramesFrom(bytes.NewReader(raw[:len(raw)-1]), 100, func(p []byte) interface{} { return string(p) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) || cb.Len() != 3 {
+		t.Fatal(err, cb.Len())
+	}
+}
+
+func TestReadFramesFromLimit(t *testing.T) {
+	cb := New(3)
+	_, err := cb.ReadFramesFrom(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}), 1024, func(p []byte) interface{} { return p })
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+	if _, err := cb.ReadFramesFrom(bytes.NewReader([]byte{0, 0, 0, 1, 'x'}), -1, func(p []byte) interface{} { return p }); !errors.Is(err, ErrInvalidSize) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package cyclicbuffer
+
+// Subscribe returns a channel which gets every item appended after the
+// call, like "tail -f", and a function which cancels the subscription
+// and closes the channel
+// The channel keeps up to Cap() items. Append never blocks: if the
+// subscriber does not read fast enough the new items are dropped
+// for this subscriber
+func (cb *CyclicBuffer) Subscribe() (<-chan interface{}, func()) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	size := cb.size
+	if size < 1 {
+		size = 1
+	}
+	ch := make(chan interface{}, size)
+	cb.subscribers = append(cb.subscribers, ch)
+	return ch, func() {
+		cb.mutex.Lock()
+		defer cb.mutex.Unlock()
+		cb.unsubscribe(ch)
+	}
+}
+
+// unsubscribe removes the channel and closes it. Calling unsubscribe
+// for a removed channel does nothing. The caller holds the mutex
+func (cb *CyclicBuffer) unsubscribe(ch chan interface{}) {
+	for i, s := range cb.subscribers {
+		if s == ch {
+			cb.subscribers = append(cb.subscribers[:i], cb.subscribers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// fanOut sends the item to the subscribers which have room in the
+// channel. The caller holds the mutex
+func (cb *CyclicBuffer) fanOut(d interface{}) {
+	for _, ch := range cb.subscribers {
+		select {
+		case ch <- d:
+		default:
+		}
+	}
+}
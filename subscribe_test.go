@@ -0,0 +1,40 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	cb := New(2)
+	cb.Append(0)
+	ch, cancel := cb.Subscribe()
+	slow, cancelSlow := cb.Subscribe()
+	for i := 1; i <= 4; i++ {
+		cb.Append(i)
+		if v := <-ch; v != i {
+			t.Fatal(v)
+		}
+	}
+	if v := <-slow; v != 1 {
+		t.Fatal(v)
+	}
+	if v := <-slow; v != 2 {
+		t.Fatal(v)
+	}
+	select {
+	case v := <-slow:
+		t.Fatal(v)
+	default:
+	}
+	cancel()
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fatal()
+	}
+	cb.Append(5)
+	if v := <-slow; v != 5 {
+		t.Fatal(v)
+	}
+	cancelSlow()
+	cb.Append(6)
+}
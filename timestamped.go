@@ -0,0 +1,96 @@
+package cyclicbuffer
+
+import (
+	"time"
+)
+
+// TimedValue is an item and the time the item was appended
+type TimedValue struct {
+	Value interface{}
+	Time  time.Time
+}
+
+// TimestampedBuffer is a cyclic buffer which keeps the time of
+// every Append(). I use this buffer for event logs
+type TimestampedBuffer struct {
+	cb *CyclicBuffer
+}
+
+// NewTimestamped creates a buffer
+func NewTimestamped(size int) *TimestampedBuffer {
+	return &TimestampedBuffer{cb: New(size)}
+}
+
+// Append adds an item and the current time to the buffer
+// Returns position of the next entry
+func (tb *TimestampedBuffer) Append(d interface{}) int {
+	tb.cb.mutex.Lock()
+	defer tb.cb.mutex.Unlock()
+	return tb.cb.appendLocked(TimedValue{Value: d, Time: time.Now()})
+}
+
+// Len returns the number of items stored in the buffer
+func (tb *TimestampedBuffer) Len() int {
+	return tb.cb.Len()
+}
+
+// Get returns a copy of the stored items, oldest first
+func (tb *TimestampedBuffer) Get() []interface{} {
+	return tb.filter(func(TimedValue) bool { return true })
+}
+
+// GetWithTimes returns a copy of the stored items and the times
+// the items were appended, oldest first
+func (tb *TimestampedBuffer) GetWithTimes() []TimedValue {
+	tb.cb.mutex.RLock()
+	defer tb.cb.mutex.RUnlock()
+	res := make([]TimedValue, 0, tb.cb.len())
+	tb.cb.walk(func(_ int, d interface{}) bool {
+		res = append(res, d.(TimedValue))
+		return true
+	})
+	return res
+}
+
+// Since returns the items appended after t, oldest first
+// Use t returned by time.Now(), the times are compared using the
+// monotonic clock reading
+func (tb *TimestampedBuffer) Since(t time.Time) []interface{} {
+	return tb.filter(func(tv TimedValue) bool { return tv.Time.After(t) })
+}
+
+// GetRecent returns the items appended not earlier than maxAge
+// before now, oldest first. I pass now explicitly to make the tests
+// simple, usually now is time.Now()
+func (tb *TimestampedBuffer) GetRecent(maxAge time.Duration, now time.Time) []interface{} {
+	return tb.filter(func(tv TimedValue) bool { return now.Sub(tv.Time) <= maxAge })
+}
+
+// TimeBounds returns the times of the oldest and the newest items
+// in one lock. Returns false if the buffer is empty
+func (tb *TimestampedBuffer) TimeBounds() (earliest, latest time.Time, ok bool) {
+	tb.cb.mutex.RLock()
+	defer tb.cb.mutex.RUnlock()
+	count := tb.cb.len()
+	if count == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	earliest = tb.cb.data[tb.cb.physical(0)].(TimedValue).Time
+	latest = tb.cb.data[tb.cb.physical(count-1)].(TimedValue).Time
+	return earliest, latest, true
+}
+
+// filter returns the items for which pred returns true, oldest first
+func (tb *TimestampedBuffer) filter(pred func(TimedValue) bool) []interface{} {
+	tb.cb.mutex.RLock()
+	defer tb.cb.mutex.RUnlock()
+	res := []interface{}{}
+	tb.cb.walk(func(_ int, d interface{}) bool {
+		tv := d.(TimedValue)
+		if pred(tv) {
+			res = append(res, tv.Value)
+		}
+		return true
+	})
+	return res
+}
@@ -0,0 +1,89 @@
+package cyclicbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestamped(t *testing.T) {
+	tb := NewTimestamped(3)
+	tb.Append(1)
+	tb.Append(2)
+	time.Sleep(2 * time.Millisecond)
+	mid := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	tb.Append(3)
+	tb.Append(4)
+	g := tb.GetWithTimes()
+	if len(g) != 3 || g[0].Value != 2 || g[2].Value != 4 || g[0].Time.After(g[1].Time) {
+		t.Fatal(g)
+	}
+	s := tb.Since(mid)
+	if len(s) != 2 || s[0] != 3 {
+		t.Fatal(s)
+	}
+	if v := tb.Get(); len(v) != 3 || v[0] != 2 {
+		t.Fatal(v)
+	}
+}
+
+func TestGetRecent(t *testing.T) {
+	tb := NewTimestamped(4)
+	tb.Append(1)
+	time.Sleep(2 * time.Millisecond)
+	tb.Append(2)
+	now := time.Now()
+	if g := tb.GetRecent(time.Minute, now); len(g) != 2 || g[0] != 1 {
+		t.Fatal(g)
+	}
+	if g := tb.GetRecent(time.Minute, now.Add(time.Hour)); len(g) != 0 {
+		t.Fatal(g)
+	}
+	times := tb.GetWithTimes()
+	if g := tb.GetRecent(time.Minute, times[1].Time.Add(time.Minute)); len(g) != 1 || g[0] != 2 {
+		t.Fatal(g)
+	}
+}
+
+func TestTimeBounds(t *testing.T) {
+	tb := NewTimestamped(2)
+	if _, _, ok := tb.TimeBounds(); ok {
+		t.Fatal()
+	}
+	tb.Append(1)
+	e, l, ok := tb.TimeBounds()
+	if !ok || !e.Equal(l) {
+		t.Fatal(e, l)
+	}
+	first := e
+	time.Sleep(time.Millisecond)
+	tb.Append(2)
+	tb.Append(3)
+	e, l, ok = tb.TimeBounds()
+	if !ok || !e.After(first) || l.Before(e) {
+		t.Fatal(e, l)
+	}
+
+	now := time.Unix(100, 0)
+	ttl := NewTTL(3, func() time.Time { return now })
+	if _, _, ok := ttl.TimeBounds(); ok {
+		t.Fatal()
+	}
+	ttl.AppendWithTimestampAndExpire(1, time.Second)
+	now = now.Add(time.Second / 2)
+	ttl.AppendWithTimestampAndExpire(2, time.Second)
+	ttl.AppendWithTimestampAndExpire(3, time.Second)
+	e, l, ok = ttl.TimeBounds()
+	if !ok || !e.Equal(time.Unix(100, 0)) || !l.Equal(now) {
+		t.Fatal(e, l)
+	}
+	ttl.AppendWithTimestampAndExpire(4, time.Second) // evicts 1
+	e, _, _ = ttl.TimeBounds()
+	if !e.Equal(now) {
+		t.Fatal(e)
+	}
+	now = now.Add(2 * time.Second)
+	if _, _, ok := ttl.TimeBounds(); ok {
+		t.Fatal()
+	}
+}
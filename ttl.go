@@ -0,0 +1,110 @@
+package cyclicbuffer
+
+import (
+	"time"
+)
+
+// TTLBuffer is a cyclic buffer where every item has an expiration time
+// The expired items are not visible to the read API. The expired items
+// at the oldest end of the buffer are removed, Len() counts only the
+// live items
+type TTLBuffer struct {
+	cb  *CyclicBuffer
+	now func() time.Time
+}
+
+// ttlEntry is an item stored in the TTLBuffer
+type ttlEntry struct {
+	value   interface{}
+	added   time.Time
+	expires time.Time
+}
+
+// NewTTL creates a buffer
+// The clock is used for the expiration, if now is nil time.Now is used
+func NewTTL(size int, now func() time.Time) *TTLBuffer {
+	if now == nil {
+		now = time.Now
+	}
+	return &TTLBuffer{cb: New(size), now: now}
+}
+
+// AppendWithTimestampAndExpire adds an item which expires after ttl
+// Returns position of the next entry
+func (tb *TTLBuffer) AppendWithTimestampAndExpire(d interface{}, ttl time.Duration) int {
+	tb.cb.mutex.Lock()
+	defer tb.cb.mutex.Unlock()
+	now := tb.now()
+	tb.expire(now)
+	return tb.cb.appendLocked(ttlEntry{value: d, added: now, expires: now.Add(ttl)})
+}
+
+// Len returns the number of live items stored in the buffer
+func (tb *TTLBuffer) Len() int {
+	tb.cb.mutex.Lock()
+	defer tb.cb.mutex.Unlock()
+	now := tb.now()
+	tb.expire(now)
+	count := 0
+	tb.cb.walk(func(_ int, d interface{}) bool {
+		if now.Before(d.(ttlEntry).expires) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// Get returns a copy of the live items, oldest first
+func (tb *TTLBuffer) Get() []interface{} {
+	tb.cb.mutex.Lock()
+	defer tb.cb.mutex.Unlock()
+	now := tb.now()
+	tb.expire(now)
+	res := make([]interface{}, 0, tb.cb.len())
+	tb.cb.walk(func(_ int, d interface{}) bool {
+		e := d.(ttlEntry)
+		if now.Before(e.expires) {
+			res = append(res, e.value)
+		}
+		return true
+	})
+	return res
+}
+
+// TimeBounds returns the append times of the oldest and the newest
+// live items in one lock. Returns false if there are no live items
+func (tb *TTLBuffer) TimeBounds() (earliest, latest time.Time, ok bool) {
+	tb.cb.mutex.Lock()
+	defer tb.cb.mutex.Unlock()
+	now := tb.now()
+	tb.expire(now)
+	tb.cb.walk(func(_ int, d interface{}) bool {
+		e := d.(ttlEntry)
+		if now.Before(e.expires) {
+			if !ok {
+				earliest, ok = e.added, true
+			}
+			latest = e.added
+		}
+		return true
+	})
+	return earliest, latest, ok
+}
+
+// CreateIterator returns a new iterator over the live items
+func (tb *TTLBuffer) CreateIterator() *Iterator {
+	return newIterator(tb.Get())
+}
+
+// expire removes the expired items from the oldest end of the buffer
+// The caller holds the mutex
+func (tb *TTLBuffer) expire(now time.Time) {
+	for tb.cb.len() > 0 {
+		e := tb.cb.data[tb.cb.start].(ttlEntry)
+		if now.Before(e.expires) {
+			return
+		}
+		tb.cb.popLocked()
+	}
+}
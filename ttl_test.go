@@ -0,0 +1,36 @@
+package cyclicbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestTTL(t *testing.T) {
+	c := &fakeClock{time.Unix(1000, 0)}
+	tb := NewTTL(4, c.now)
+	tb.AppendWithTimestampAndExpire(1, time.Second)
+	tb.AppendWithTimestampAndExpire(2, 10*time.Second)
+	tb.AppendWithTimestampAndExpire(3, time.Second)
+	if tb.Len() != 3 {
+		t.Fatal()
+	}
+	c.t = c.t.Add(2 * time.Second)
+	if g := tb.Get(); len(g) != 1 || g[0] != 2 {
+		t.Fatal(g)
+	}
+	if tb.Len() != 1 {
+		t.Fatal(tb.Len())
+	}
+	it := tb.CreateIterator()
+	if !it.Next() || it.Value() != 2 || it.Next() {
+		t.Fatal()
+	}
+	c.t = c.t.Add(10 * time.Second)
+	if tb.Len() != 0 || len(tb.Get()) != 0 {
+		t.Fatal()
+	}
+}
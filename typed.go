@@ -0,0 +1,35 @@
+package cyclicbuffer
+
+import (
+	"fmt"
+)
+
+// GetInts returns a copy of the stored data, oldest first, for a
+// buffer of ints. Returns an error if an item is not an int
+func (cb *CyclicBuffer) GetInts() ([]int, error) {
+	data := cb.Get()
+	res := make([]int, 0, len(data))
+	for i, d := range data {
+		v, ok := d.(int)
+		if !ok {
+			return nil, fmt.Errorf("%w: item %d is %T, not int", ErrInvalidType, i, d)
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// GetStrings returns a copy of the stored data, oldest first, for a
+// buffer of strings. Returns an error if an item is not a string
+func (cb *CyclicBuffer) GetStrings() ([]string, error) {
+	data := cb.Get()
+	res := make([]string, 0, len(data))
+	for i, d := range data {
+		v, ok := d.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: item %d is %T, not string", ErrInvalidType, i, d)
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
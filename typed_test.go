@@ -0,0 +1,26 @@
+package cyclicbuffer
+
+import (
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	cb := New(3)
+	cb.Append(1)
+	cb.Append(2)
+	if v, err := cb.GetInts(); err != nil || len(v) != 2 || v[1] != 2 {
+		t.Fatal(v, err)
+	}
+	cb.Append("a")
+	if _, err := cb.GetInts(); err == nil {
+		t.Fatal()
+	}
+	s := New(2)
+	s.Append("x")
+	if v, err := s.GetStrings(); err != nil || v[0] != "x" {
+		t.Fatal(err)
+	}
+	if _, err := cb.GetStrings(); err == nil {
+		t.Fatal()
+	}
+}
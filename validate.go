@@ -0,0 +1,46 @@
+package cyclicbuffer
+
+import (
+	"fmt"
+)
+
+// Validate checks the internal invariants of the buffer
+// Returns an error describing the first broken invariant
+// I call Validate in the tests after every operation
+func (cb *CyclicBuffer) Validate() error {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	if cb.size < 0 || cb.size != len(cb.data) || cb.size != len(cb.seqs) {
+		return fmt.Errorf("%w: size %d, %d slots, %d sequence numbers", ErrInvalidState, cb.size, len(cb.data), len(cb.seqs))
+	}
+	if cb.size == 0 {
+		if cb.index != 0 || cb.start != 0 || cb.full {
+			return fmt.Errorf("%w: index %d, start %d, full %v in a buffer of size 0", ErrInvalidState, cb.index, cb.start, cb.full)
+		}
+		return nil
+	}
+	if cb.index < 0 || cb.index >= cb.size {
+		return fmt.Errorf("%w: index %d is out of [0, %d)", ErrInvalidState, cb.index, cb.size)
+	}
+	if cb.start < 0 || cb.start >= cb.size {
+		return fmt.Errorf("%w: start %d is out of [0, %d)", ErrInvalidState, cb.start, cb.size)
+	}
+	if cb.full && cb.index != cb.start {
+		return fmt.Errorf("%w: full buffer, index %d, start %d", ErrInvalidState, cb.index, cb.start)
+	}
+	count := cb.len()
+	for i := count; i < cb.size; i++ {
+		if d := cb.data[cb.physical(i)]; d != nil && !equal(d, cb.prefill) {
+			return fmt.Errorf("%w: free slot %d keeps %v", ErrInvalidState, cb.physical(i), d)
+		}
+	}
+	for i := 1; i < count; i++ {
+		if cb.seqs[cb.physical(i-1)] >= cb.seqs[cb.physical(i)] {
+			return fmt.Errorf("%w: sequence number of the item %d is not ascending", ErrInvalidState, i)
+		}
+	}
+	if count > 0 && cb.seqs[cb.physical(count-1)] > cb.seq {
+		return fmt.Errorf("%w: sequence number of the newest item exceeds %d", ErrInvalidState, cb.seq)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cyclicbuffer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	cb := New(5)
+	for i := 0; i < 5000; i++ {
+		switch r.Intn(10) {
+		case 0:
+			cb.Pop()
+		case 1:
+			cb.Rotate(r.Intn(3))
+		case 2:
+			cb.Resize(r.Intn(8))
+		case 3:
+			cb.Trim(r.Intn(4))
+		case 4:
+			cb.Swap([]interface{}{1, 2, 3}[:r.Intn(3)])
+		case 5:
+			cb.Grow(r.Intn(2))
+		case 6:
+			if r.Intn(20) == 0 {
+				cb.Clear()
+			}
+		default:
+			cb.Append(i)
+		}
+		if err := cb.Validate(); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+	cb.SetUniqueKey(func(d interface{}) string { return string(rune('a' + d.(int)%3)) })
+	for i := 0; i < 100; i++ {
+		cb.Append(i)
+		if err := cb.Validate(); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+	b := New(3)
+	b.Append(1)
+	b.full = true
+	if b.Validate() == nil {
+		t.Fatal("expected an error")
+	}
+}